@@ -0,0 +1,76 @@
+package template
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// SourceDateEpochEnv names the environment variable reproducible builds use to
+// pin the current time, as seconds since the Unix epoch.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// Clock tells the current time. The engine and hooks read the time through a
+// Clock so generated output can be made reproducible.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// FixedClock returns a Clock that always reports t.
+func FixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
+
+// DefaultClock returns the clock used when none is configured. It reports the
+// time set by SOURCE_DATE_EPOCH, in UTC, when the variable holds a valid
+// timestamp and the system time otherwise.
+func DefaultClock() Clock {
+	return ClockFunc(func() time.Time {
+		if t, ok := SourceDateEpoch(); ok {
+			return t
+		}
+		return time.Now()
+	})
+}
+
+// SourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH environment
+// variable. ok is false when the variable is unset or not an integer.
+func SourceDateEpoch() (t time.Time, ok bool) {
+	value := os.Getenv(SourceDateEpochEnv)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0).UTC(), true
+}
+
+// WithClock sets the clock behind the `now` template global and
+// HookContext.Clock, DefaultClock by default.
+func WithClock(clock Clock) Option {
+	return func(e *Engine) {
+		if clock != nil {
+			e.clock = clock
+		}
+	}
+}
+
+// Clock returns the engine clock.
+func (r *Engine) Clock() Clock {
+	return r.clock
+}
+
+// now backs the `now` template global.
+func (r *Engine) now() time.Time {
+	return r.clock.Now()
+}
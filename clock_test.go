@@ -0,0 +1,74 @@
+package template_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceDateEpoch(t *testing.T) {
+	t.Setenv(template.SourceDateEpochEnv, "1700000000")
+
+	got, ok := template.SourceDateEpoch()
+	require.True(t, ok)
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), got)
+	require.Equal(t, got, template.DefaultClock().Now())
+
+	t.Setenv(template.SourceDateEpochEnv, "yesterday")
+	_, ok = template.SourceDateEpoch()
+	require.False(t, ok)
+	require.WithinDuration(t, time.Now(), template.DefaultClock().Now(), time.Minute)
+}
+
+func TestEngine_NowGlobal(t *testing.T) {
+	fsys := fstest.MapFS{
+		"stamp.tpl": {Data: []byte(`{{ now()|date:"2006-01-02 15:04" }}`)},
+	}
+	fixed := time.Date(2024, 3, 9, 18, 30, 0, 0, time.UTC)
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys), template.WithClock(template.FixedClock(fixed)))
+	require.NoError(t, err)
+	require.Equal(t, fixed, renderer.Clock().Now())
+
+	out, err := renderer.RenderTemplate("stamp", nil)
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-09 18:30", out)
+
+	out, err = renderer.RenderString(`{{ now()|date:"2006" }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "2024", out)
+}
+
+func TestEngine_NowGlobal_SourceDateEpoch(t *testing.T) {
+	t.Setenv(template.SourceDateEpochEnv, "0")
+
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{}))
+	require.NoError(t, err)
+
+	out, err := renderer.RenderString(`{{ now()|date:"2006-01-02" }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "1970-01-01", out)
+}
+
+func TestEngine_HookContextClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 9, 18, 30, 0, 0, time.UTC)
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{}), template.WithClock(template.FixedClock(fixed)))
+	require.NoError(t, err)
+
+	var seen []time.Time
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		seen = append(seen, ctx.Clock.Now())
+		return nil
+	})
+	renderer.RegisterPostHook(func(ctx *template.HookContext) (string, error) {
+		seen = append(seen, ctx.Clock.Now())
+		return ctx.Output, nil
+	})
+
+	_, err = renderer.RenderString("x", nil)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{fixed, fixed}, seen)
+}
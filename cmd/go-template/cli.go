@@ -0,0 +1,421 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/data"
+	"github.com/goliatone/go-template/templatehooks"
+)
+
+// Exit codes returned by run.
+const (
+	exitOK     = 0
+	exitRender = 1
+	exitUsage  = 2
+)
+
+// cliError is reported on stderr, as text or JSON depending on -error-format.
+type cliError struct {
+	Code     int    `json:"code"`
+	Phase    string `json:"phase"`
+	Template string `json:"template,omitempty"`
+	Message  string `json:"error"`
+}
+
+func (e *cliError) Error() string {
+	if e.Template != "" {
+		return fmt.Sprintf("%s %s: %s", e.Phase, e.Template, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Phase, e.Message)
+}
+
+func newError(code int, phase, name string, err error) *cliError {
+	return &cliError{Code: code, Phase: phase, Template: name, Message: err.Error()}
+}
+
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+type config struct {
+	dir              string
+	ext              string
+	out              string
+	dataFiles        stringList
+	sets             stringList
+	envPrefix        string
+	license          string
+	copyright        string
+	generatedWarning bool
+	trimWhitespace   bool
+	dryRun           bool
+	stateDir         string
+	failOnConflict   bool
+	lockfile         string
+	check            bool
+	skipUnchanged    bool
+	writePolicy      string
+	errorFormat      string
+	target           string
+	args             []string
+}
+
+func parseFlags(args []string, stderr io.Writer) (*config, error) {
+	cfg := &config{}
+
+	flags := flag.NewFlagSet("go-template", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: go-template [flags] <template|directory> [key=value...]")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&cfg.dir, "dir", ".", "base directory holding the templates")
+	flags.StringVar(&cfg.ext, "ext", ".tpl", "template file extension")
+	flags.StringVar(&cfg.out, "out", "", "output file (single template) or directory (directory mode); stdout when empty")
+	flags.Var(&cfg.dataFiles, "data", "data file (yaml, json, toml or .env); repeatable, later files win")
+	flags.Var(&cfg.sets, "set", "key=value data override using dotted keys; repeatable")
+	flags.StringVar(&cfg.envPrefix, "env-prefix", "", "load environment variables with this prefix as data")
+	flags.StringVar(&cfg.license, "license", "", "file holding a license header to prepend to code outputs")
+	flags.StringVar(&cfg.copyright, "copyright", "", "copyright line to prepend to code outputs")
+	flags.BoolVar(&cfg.generatedWarning, "generated-warning", false, "prepend a generated code warning to Go outputs")
+	flags.BoolVar(&cfg.trimWhitespace, "trim-whitespace", false, "remove trailing whitespace from every line")
+	flags.BoolVar(&cfg.dryRun, "dry-run", false, "print the changes -out would receive as unified diffs instead of writing")
+	flags.StringVar(&cfg.stateDir, "state-dir", "", "directory keeping the previous output; directory mode merges edits made under -out")
+	flags.BoolVar(&cfg.failOnConflict, "fail-on-conflict", false, "with -state-dir, fail instead of writing conflict markers")
+	flags.StringVar(&cfg.lockfile, "lock", "", "lockfile recording generated files; written in directory mode with -out")
+	flags.BoolVar(&cfg.check, "check", false, "report files drifted or stale against -lock instead of generating")
+	flags.BoolVar(&cfg.skipUnchanged, "skip-unchanged", false, "leave up to date files untouched")
+	flags.StringVar(&cfg.writePolicy, "write-policy", "", "default write policy: always, create-only, overwrite-if-generated or skip-if-identical")
+	flags.StringVar(&cfg.errorFormat, "error-format", "text", "error output format: text or json")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.errorFormat != "text" && cfg.errorFormat != "json" {
+		return cfg, fmt.Errorf("invalid -error-format %q", cfg.errorFormat)
+	}
+
+	rest := flags.Args()
+	if len(rest) == 0 {
+		flags.Usage()
+		return cfg, errors.New("missing template or directory argument")
+	}
+
+	if _, err := template.ParseWritePolicy(cfg.writePolicy); err != nil {
+		return cfg, err
+	}
+
+	if cfg.check && cfg.lockfile == "" {
+		return cfg, errors.New("-check requires -lock")
+	}
+
+	cfg.target = rest[0]
+	cfg.args = rest[1:]
+	if !strings.HasPrefix(cfg.ext, ".") {
+		cfg.ext = "." + cfg.ext
+	}
+
+	return cfg, nil
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	cfg, err := parseFlags(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		format := "text"
+		if cfg != nil {
+			format = cfg.errorFormat
+		}
+		return report(stderr, format, newError(exitUsage, "usage", "", err))
+	}
+
+	if err := execute(cfg, stdout); err != nil {
+		var cerr *cliError
+		if !errors.As(err, &cerr) {
+			cerr = newError(exitRender, "render", "", err)
+		}
+		return report(stderr, cfg.errorFormat, cerr)
+	}
+
+	return exitOK
+}
+
+func report(w io.Writer, format string, err *cliError) int {
+	if format == "json" {
+		_ = json.NewEncoder(w).Encode(err)
+	} else {
+		fmt.Fprintf(w, "go-template: %s\n", err.Error())
+	}
+	return err.Code
+}
+
+func execute(cfg *config, stdout io.Writer) error {
+	values, err := loadData(cfg)
+	if err != nil {
+		return newError(exitUsage, "data", "", err)
+	}
+
+	renderer, err := template.NewRenderer(
+		template.WithBaseDir(cfg.dir),
+		template.WithExtension(cfg.ext),
+	)
+	if err != nil {
+		return newError(exitUsage, "load", "", err)
+	}
+
+	if err := registerHooks(cfg, renderer); err != nil {
+		return newError(exitUsage, "hooks", "", err)
+	}
+
+	if cfg.check {
+		return checkLock(cfg, renderer, values, stdout)
+	}
+
+	if isDirectory(cfg) {
+		return renderTree(cfg, renderer, values, stdout)
+	}
+
+	name := strings.TrimSuffix(filepath.ToSlash(cfg.target), cfg.ext)
+	output, err := renderer.RenderTemplate(name, values)
+	if err != nil {
+		return newError(exitRender, "render", name, err)
+	}
+
+	if cfg.out == "" {
+		if _, err := io.WriteString(stdout, output); err != nil {
+			return newError(exitRender, "write", name, err)
+		}
+		return nil
+	}
+
+	meta, err := renderer.Metadata(name)
+	if err != nil {
+		return newError(exitRender, "render", name, err)
+	}
+
+	file := template.GeneratedFile{Path: filepath.Base(cfg.out), Source: name, Content: []byte(output), Mode: 0o644}
+	if meta != nil {
+		file.Policy = meta.WritePolicy
+	}
+
+	// write through the same path as directory mode so -write-policy and
+	// -skip-unchanged apply
+	if cfg.dryRun {
+		err = printPlan(cfg, stdout, filepath.Dir(cfg.out), []template.GeneratedFile{file})
+	} else {
+		_, err = template.WriteFilesAtomic(filepath.Dir(cfg.out), []template.GeneratedFile{file}, writeOptions(cfg)...)
+	}
+	if err != nil {
+		return newError(exitRender, "write", name, err)
+	}
+
+	return nil
+}
+
+// renderTree renders a template directory with Engine.GenerateTree, so path
+// segments are templated and non template files are copied verbatim.
+func renderTree(cfg *config, renderer *template.Engine, values map[string]any, stdout io.Writer) error {
+	files, err := renderer.GenerateTree(cfg.target, values)
+	if err != nil {
+		return newError(exitRender, "render", cfg.target, err)
+	}
+
+	if cfg.dryRun && cfg.out != "" {
+		previous, err := lockedPaths(cfg)
+		if err != nil {
+			return newError(exitUsage, "plan", cfg.target, err)
+		}
+		if err := printPlan(cfg, stdout, cfg.out, files, template.WithPlanPrevious(previous...)); err != nil {
+			return newError(exitRender, "plan", cfg.target, err)
+		}
+		return nil
+	}
+
+	if cfg.out != "" {
+		if cfg.stateDir != "" {
+			if err := mergeTree(cfg, files, stdout); err != nil {
+				return err
+			}
+		} else if _, err := template.WriteFilesAtomic(cfg.out, files, writeOptions(cfg)...); err != nil {
+			return newError(exitRender, "write", cfg.target, err)
+		}
+		return writeLock(cfg, renderer, files, values)
+	}
+
+	for _, file := range files {
+		if _, err := fmt.Fprintf(stdout, "==> %s <==\n%s", file.Path, file.Content); err != nil {
+			return newError(exitRender, "write", file.Source, err)
+		}
+	}
+	return nil
+}
+
+// mergeTree writes files with a three-way merge against the previous output
+// kept in -state-dir, listing merged and conflicting files.
+func mergeTree(cfg *config, files []template.GeneratedFile, stdout io.Writer) error {
+	results, err := template.MergeFiles(cfg.out, cfg.stateDir, files, template.WithMergeFailOnConflict(cfg.failOnConflict))
+	if err != nil {
+		return newError(exitRender, "merge", cfg.target, err)
+	}
+
+	conflicts := 0
+	for _, result := range results {
+		switch result.Status {
+		case template.MergeMerged, template.MergeConflict:
+			fmt.Fprintf(stdout, "%-9s %s\n", result.Status, result.Path)
+		}
+		conflicts += result.Conflicts
+	}
+	if conflicts > 0 {
+		return newError(exitRender, "merge", cfg.target, fmt.Errorf("%d conflicts written with markers", conflicts))
+	}
+	return nil
+}
+
+func writeOptions(cfg *config) []template.WriteOption {
+	policy, _ := template.ParseWritePolicy(cfg.writePolicy)
+	return []template.WriteOption{
+		template.WithWriteSkipUnchanged(cfg.skipUnchanged),
+		template.WithWritePolicy(policy),
+	}
+}
+
+// writeLock records files in -lock with the content they have in -out, which
+// differs from the generated content for merged files and files kept by the
+// write policy, so -check does not report them as drifted.
+func writeLock(cfg *config, renderer *template.Engine, files []template.GeneratedFile, values map[string]any) error {
+	if cfg.lockfile == "" {
+		return nil
+	}
+
+	written := make([]template.GeneratedFile, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(cfg.out, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return newError(exitRender, "lock", cfg.target, err)
+		}
+		file.Content = content
+		written = append(written, file)
+	}
+
+	lock, err := renderer.Lock(written, values)
+	if err == nil {
+		err = lock.Write(cfg.lockfile)
+	}
+	if err != nil {
+		return newError(exitRender, "lock", cfg.target, err)
+	}
+	return nil
+}
+
+// lockedPaths returns the paths recorded in -lock, so a dry run reports files
+// no longer generated as deleted. A missing lockfile records no paths.
+func lockedPaths(cfg *config) ([]string, error) {
+	if cfg.lockfile == "" {
+		return nil, nil
+	}
+
+	lock, err := template.ReadLockfile(cfg.lockfile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(lock.Files))
+	for _, entry := range lock.Files {
+		paths = append(paths, entry.Path)
+	}
+	return paths, nil
+}
+
+// checkLock reports every drifted, missing or stale file listed in -lock. The
+// output directory defaults to the lockfile directory.
+func checkLock(cfg *config, renderer *template.Engine, values map[string]any, stdout io.Writer) error {
+	lock, err := template.ReadLockfile(cfg.lockfile)
+	if err != nil {
+		return newError(exitUsage, "check", cfg.target, err)
+	}
+
+	dest := cfg.out
+	if dest == "" {
+		dest = filepath.Dir(cfg.lockfile)
+	}
+
+	issues, err := renderer.CheckLock(dest, lock, values)
+	for _, issue := range issues {
+		fmt.Fprintln(stdout, issue.String())
+	}
+	if err != nil {
+		return newError(exitRender, "check", cfg.target, err)
+	}
+	return nil
+}
+
+func printPlan(cfg *config, w io.Writer, dest string, files []template.GeneratedFile, opts ...template.PlanOption) error {
+	policy, _ := template.ParseWritePolicy(cfg.writePolicy)
+	opts = append([]template.PlanOption{template.WithPlanWritePolicy(policy)}, opts...)
+	changes, err := template.Plan(dest, files, opts...)
+	if err != nil {
+		return err
+	}
+	return template.WritePlan(w, changes)
+}
+
+func loadData(cfg *config) (map[string]any, error) {
+	loader := data.NewLoader()
+	for _, file := range cfg.dataFiles {
+		loader.Add(data.File(file))
+	}
+	if cfg.envPrefix != "" {
+		loader.Add(data.Env(cfg.envPrefix))
+	}
+	loader.Add(data.Args(cfg.sets), data.Args(cfg.args))
+	return loader.Load()
+}
+
+func registerHooks(cfg *config, renderer *template.Engine) error {
+	hooks := templatehooks.NewCommonHooks()
+
+	if cfg.license != "" {
+		license, err := os.ReadFile(cfg.license)
+		if err != nil {
+			return fmt.Errorf("unable to read license: %w", err)
+		}
+		renderer.RegisterPostHook(hooks.AddLicenseHook(strings.TrimRight(string(license), "\n")))
+	}
+	if cfg.copyright != "" {
+		renderer.RegisterPostHook(hooks.AddCopyrightHook(cfg.copyright))
+	}
+	if cfg.generatedWarning {
+		renderer.RegisterPostHook(hooks.AddGeneratedWarningHook())
+	}
+	if cfg.trimWhitespace {
+		renderer.RegisterPostHook(hooks.RemoveTrailingWhitespaceHook())
+	}
+	return nil
+}
+
+func isDirectory(cfg *config) bool {
+	info, err := os.Stat(filepath.Join(cfg.dir, cfg.target))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestRun_SingleTemplateToStdout(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"greet.tpl": "Hello {{ name }} from {{ app.env }}!",
+		"data.yaml": "name: Ada\napp:\n  env: dev\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{
+		"-dir", dir,
+		"-data", filepath.Join(dir, "data.yaml"),
+		"-set", "app.env=prod",
+		"greet",
+	}, &stdout, &stderr)
+
+	require.Equal(t, exitOK, code, stderr.String())
+	require.Equal(t, "Hello Ada from prod!", stdout.String())
+}
+
+func TestRun_TrailingArgumentsAndHooks(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"model.go.tpl": "package {{ pkg }}   \n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{
+		"-dir", dir,
+		"-generated-warning",
+		"-trim-whitespace",
+		"model.go",
+		"pkg=models",
+	}, &stdout, &stderr)
+
+	require.Equal(t, exitOK, code, stderr.String())
+	require.Equal(t, "// Code generated by go-template. DO NOT EDIT.\npackage models\n", stdout.String())
+}
+
+func TestRun_DirectoryToOutputTree(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"project/README.md.tpl":              "# {{ name }}",
+		"project/cmd/{{ name }}/main.go.tpl": "package main // {{ name }}",
+		"project/notes.txt":                  "copied {{ as is }}",
+	})
+	out := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-dir", dir, "-out", out, "project", "name=demo"}, &stdout, &stderr)
+	require.Equal(t, exitOK, code, stderr.String())
+
+	readme, err := os.ReadFile(filepath.Join(out, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, "# demo", string(readme))
+
+	main, err := os.ReadFile(filepath.Join(out, "cmd", "demo", "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main // demo", string(main))
+
+	notes, err := os.ReadFile(filepath.Join(out, "notes.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "copied {{ as is }}", string(notes))
+}
+
+func TestRun_RenderErrorJSON(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"broken.tpl": "{% if %}",
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-dir", dir, "-error-format", "json", "broken"}, &stdout, &stderr)
+	require.Equal(t, exitRender, code)
+
+	var reported cliError
+	require.NoError(t, json.Unmarshal(stderr.Bytes(), &reported))
+	require.Equal(t, exitRender, reported.Code)
+	require.Equal(t, "render", reported.Phase)
+	require.Equal(t, "broken", reported.Template)
+	require.NotEmpty(t, reported.Message)
+}
+
+func TestRun_UsageErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, exitUsage, run([]string{}, &stdout, &stderr))
+
+	stderr.Reset()
+	dir := writeFiles(t, map[string]string{"a.tpl": "x"})
+	code := run([]string{"-dir", dir, "-data", filepath.Join(dir, "missing.yaml"), "a"}, &stdout, &stderr)
+	require.Equal(t, exitUsage, code)
+	require.Contains(t, stderr.String(), "go-template: data:")
+
+	stderr.Reset()
+	require.Equal(t, exitUsage, run([]string{"-error-format", "xml", "a"}, &stdout, &stderr))
+}
+
+func TestRun_DryRun(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"project/{{ pkg }}/model.go.tpl": "package {{ pkg }}\n",
+		"project/README.md":              "readme\n",
+	})
+	out := writeFiles(t, map[string]string{
+		"README.md": "old readme\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-dir", dir, "-out", out, "-dry-run", "project", "pkg=models"}, &stdout, &stderr)
+
+	require.Equal(t, exitOK, code, stderr.String())
+	require.Contains(t, stdout.String(), "modified  README.md\n--- a/README.md\n+++ b/README.md\n")
+	require.Contains(t, stdout.String(), "created   models/model.go\n")
+	require.Contains(t, stdout.String(), "1 created, 1 modified, 0 unchanged, 0 deleted\n")
+
+	_, err := os.Stat(filepath.Join(out, "models"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestRun_SingleOutputWritePolicy(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"model.go.tpl": "package {{ pkg }}\n",
+	})
+	out := writeFiles(t, map[string]string{
+		"model.go": "package edited\n",
+	})
+	target := filepath.Join(out, "model.go")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-dir", dir, "-out", target, "-write-policy", "create-only"}
+
+	require.Equal(t, exitOK, run(append(args, "-dry-run", "model.go", "pkg=models"), &stdout, &stderr), stderr.String())
+	require.Contains(t, stdout.String(), "kept      model.go (file exists)\n")
+
+	require.Equal(t, exitOK, run(append(args, "model.go", "pkg=models"), &stdout, &stderr), stderr.String())
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "package edited\n", string(content))
+
+	fresh := filepath.Join(t.TempDir(), "sub", "model.go")
+	require.Equal(t, exitOK, run([]string{"-dir", dir, "-out", fresh, "-write-policy", "create-only", "model.go", "pkg=models"}, &stdout, &stderr), stderr.String())
+	content, err = os.ReadFile(fresh)
+	require.NoError(t, err)
+	require.Equal(t, "package models\n", string(content))
+}
+
+func TestRun_StateDirMerge(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"project/main.go.tpl": "package main\n\nfunc main() {}\n\n// {{ version }}\n",
+	})
+	out := t.TempDir()
+	state := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-dir", dir, "-out", out, "-state-dir", state, "project"}
+	require.Equal(t, exitOK, run(append(args, "version=v1"), &stdout, &stderr), stderr.String())
+
+	target := filepath.Join(out, "main.go")
+	require.NoError(t, os.WriteFile(target, []byte("// edited\npackage main\n\nfunc main() {}\n\n// v1\n"), 0o644))
+
+	stdout.Reset()
+	require.Equal(t, exitOK, run(append(args, "version=v2"), &stdout, &stderr), stderr.String())
+	require.Equal(t, "merged    main.go\n", stdout.String())
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "// edited\npackage main\n\nfunc main() {}\n\n// v2\n", string(content))
+}
+
+func TestRun_LockAndCheck(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"project/main.go.tpl": "package {{ pkg }}\n",
+	})
+	out := t.TempDir()
+	lock := filepath.Join(out, ".go-template.lock")
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, exitOK, run([]string{"-dir", dir, "-out", out, "-lock", lock, "project", "pkg=main"}, &stdout, &stderr), stderr.String())
+
+	check := []string{"-dir", dir, "-lock", lock, "-check", "project", "pkg=main"}
+	require.Equal(t, exitOK, run(check, &stdout, &stderr), stderr.String())
+	require.Empty(t, stdout.String())
+
+	require.NoError(t, os.WriteFile(filepath.Join(out, "main.go"), []byte("package edited\n"), 0o644))
+
+	stderr.Reset()
+	require.Equal(t, exitRender, run(check, &stdout, &stderr))
+	require.Equal(t, "main.go: drifted (content differs from generated output)\n", stdout.String())
+	require.Contains(t, stderr.String(), "check project")
+}
+
+func TestRun_CheckAfterMergeAndKeptFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"project/main.go.tpl": "package main\n\nfunc main() {}\n\n// {{ version }}\n",
+	})
+
+	t.Run("state dir", func(t *testing.T) {
+		out := t.TempDir()
+		lock := filepath.Join(out, ".go-template.lock")
+		args := []string{"-dir", dir, "-out", out, "-lock", lock, "-state-dir", t.TempDir(), "project"}
+
+		var stdout, stderr bytes.Buffer
+		require.Equal(t, exitOK, run(append(args, "version=v1"), &stdout, &stderr), stderr.String())
+		require.NoError(t, os.WriteFile(filepath.Join(out, "main.go"), []byte("// edited\npackage main\n\nfunc main() {}\n\n// v1\n"), 0o644))
+		require.Equal(t, exitOK, run(append(args, "version=v2"), &stdout, &stderr), stderr.String())
+
+		stdout.Reset()
+		check := []string{"-dir", dir, "-lock", lock, "-check", "project", "version=v2"}
+		require.Equal(t, exitOK, run(check, &stdout, &stderr), stdout.String())
+		require.Empty(t, stdout.String())
+	})
+
+	t.Run("kept by write policy", func(t *testing.T) {
+		out := writeFiles(t, map[string]string{
+			"main.go": "package edited\n",
+		})
+		lock := filepath.Join(out, ".go-template.lock")
+
+		var stdout, stderr bytes.Buffer
+		args := []string{"-dir", dir, "-out", out, "-lock", lock, "-write-policy", "create-only", "project", "version=v1"}
+		require.Equal(t, exitOK, run(args, &stdout, &stderr), stderr.String())
+
+		stdout.Reset()
+		check := []string{"-dir", dir, "-lock", lock, "-check", "project", "version=v1"}
+		require.Equal(t, exitOK, run(check, &stdout, &stderr), stdout.String())
+		require.Empty(t, stdout.String())
+	})
+}
+
+func TestRun_DryRunReportsLockedFilesAsDeleted(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"project/main.go.tpl": "package {{ pkg }}\n",
+		"project/old.go.tpl":  "package {{ pkg }}\n",
+	})
+	out := t.TempDir()
+	lock := filepath.Join(out, ".go-template.lock")
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, exitOK, run([]string{"-dir", dir, "-out", out, "-lock", lock, "project", "pkg=main"}, &stdout, &stderr), stderr.String())
+	require.NoError(t, os.Remove(filepath.Join(dir, "project", "old.go.tpl")))
+
+	stdout.Reset()
+	require.Equal(t, exitOK, run([]string{"-dir", dir, "-out", out, "-lock", lock, "-dry-run", "project", "pkg=main"}, &stdout, &stderr), stderr.String())
+	require.Contains(t, stdout.String(), "deleted   old.go\n")
+	require.Contains(t, stdout.String(), "0 created, 0 modified, 1 unchanged, 1 deleted\n")
+
+	_, err := os.Stat(filepath.Join(out, "old.go"))
+	require.NoError(t, err, "a dry run leaves files in place")
+}
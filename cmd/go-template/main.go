@@ -0,0 +1,36 @@
+// Command go-template renders pongo2 templates with go-template.
+//
+// Usage:
+//
+//	go-template [flags] <template|directory> [key=value...]
+//
+// A template name is resolved relative to -dir and rendered to stdout or to
+// -out. When the argument is a directory the whole tree is generated with
+// Engine.GenerateTree: path segments are templated, templates are rendered and
+// other files are copied, mirroring the directory layout under -out.
+//
+// Data is merged from -data files (YAML, JSON, TOML or dotenv), environment
+// variables matching -env-prefix, -set flags and trailing key=value
+// arguments, in that order.
+//
+// With -dry-run and -out nothing is written; the files that would be created
+// or modified are printed as unified diffs against the existing output. With
+// -lock too, files recorded in the lockfile that are no longer generated are
+// reported as deleted.
+//
+// With -state-dir a directory target is merged into -out: edits made to the
+// previous output are kept and conflicting changes get conflict markers, or
+// fail the run with -fail-on-conflict.
+//
+// With -lock a directory run records every generated file, its template, data
+// and output hashes. -check -lock then exits 1 when files drifted from their
+// generated content or are stale because templates or data changed.
+package main
+
+import (
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
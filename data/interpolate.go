@@ -0,0 +1,173 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// interpolate resolves ${...} references inside string values:
+//
+//	${config.db.host}   value at a dotted path of the merged data
+//	${HOME}             environment variable, used when no data path matches
+//	${env:HOME}         environment variable only
+//	${name:-fallback}   fallback when the reference does not resolve
+//	$${literal}         escaped, rendered as ${literal}
+//
+// A string made of a single reference takes the referenced value as is, so
+// numbers, booleans and maps keep their type.
+func interpolate(root map[string]any, lookupEnv func(string) (string, bool)) error {
+	r := &interpolator{root: root, lookupEnv: lookupEnv, resolving: map[string]bool{}}
+	return r.walk(root, "")
+}
+
+type interpolator struct {
+	root      map[string]any
+	lookupEnv func(string) (string, bool)
+	resolving map[string]bool
+}
+
+func (r *interpolator) walk(node any, path string) error {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			child := joinPath(path, key)
+			if s, ok := value.(string); ok {
+				resolved, err := r.resolveValue(child, s)
+				if err != nil {
+					return err
+				}
+				v[key] = resolved
+				continue
+			}
+			if err := r.walk(value, child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, value := range v {
+			child := joinPath(path, strconv.Itoa(i))
+			if s, ok := value.(string); ok {
+				resolved, err := r.resolveValue(child, s)
+				if err != nil {
+					return err
+				}
+				v[i] = resolved
+				continue
+			}
+			if err := r.walk(value, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinPath(base, key string) string {
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
+
+func (r *interpolator) resolveValue(path, s string) (any, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	if r.resolving[path] {
+		return nil, fmt.Errorf("interpolation cycle detected at %s", path)
+	}
+	r.resolving[path] = true
+	defer delete(r.resolving, path)
+
+	if ref, ok := singleReference(s); ok {
+		value, err := r.lookup(path, ref)
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated interpolation in %s: %q", path, s)
+		}
+		value, err := r.lookup(path, s[i+2:i+end])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(stringify(value))
+		i += end + 1
+	}
+	return b.String(), nil
+}
+
+func singleReference(s string) (string, bool) {
+	if !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") {
+		return "", false
+	}
+	inner := s[2 : len(s)-1]
+	if strings.ContainsAny(inner, "{}") {
+		return "", false
+	}
+	return inner, true
+}
+
+func (r *interpolator) lookup(from, ref string) (any, error) {
+	name, fallback, hasFallback := strings.Cut(ref, ":-")
+	name = strings.TrimSpace(name)
+
+	if env, ok := strings.CutPrefix(name, "env:"); ok {
+		if value, found := r.env(env); found {
+			return value, nil
+		}
+	} else {
+		if value, found := Lookup(r.root, name); found {
+			if s, ok := value.(string); ok {
+				return r.resolveValue(name, s)
+			}
+			return value, nil
+		}
+		if value, found := r.env(name); found {
+			return value, nil
+		}
+	}
+
+	if hasFallback {
+		return fallback, nil
+	}
+	return nil, fmt.Errorf("unresolved reference ${%s} in %s", ref, from)
+}
+
+func (r *interpolator) env(name string) (string, bool) {
+	if r.lookupEnv == nil {
+		return "", false
+	}
+	return r.lookupEnv(name)
+}
+
+func stringify(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,235 @@
+// Package data loads render data for go-template from files, the environment
+// and command line arguments, merging every source into the map consumed by
+// Engine.RenderTemplate.
+package data
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Context gives sources access to the loader configuration.
+type Context struct {
+	// FS is used to read files. When nil files are read from the OS.
+	FS fs.FS
+	// LookupEnv resolves a single environment variable.
+	LookupEnv func(key string) (string, bool)
+	// Environ lists the environment as KEY=value pairs.
+	Environ func() []string
+	// InferTypes converts string values from env, dotenv and argument sources
+	// into booleans, numbers and null when they look like one.
+	InferTypes bool
+}
+
+// ReadFile reads path through FS or the OS file system.
+func (c *Context) ReadFile(path string) ([]byte, error) {
+	if c.FS != nil {
+		return fs.ReadFile(c.FS, path)
+	}
+	return os.ReadFile(path)
+}
+
+// Source produces one layer of render data.
+type Source interface {
+	Load(ctx *Context) (map[string]any, error)
+}
+
+// SourceFunc adapts a function into a Source.
+type SourceFunc func(ctx *Context) (map[string]any, error)
+
+// Load implements Source.
+func (f SourceFunc) Load(ctx *Context) (map[string]any, error) {
+	return f(ctx)
+}
+
+// Loader merges sources in the order they were added: values from later
+// sources override earlier ones. Maps are merged recursively, any other value
+// (arrays included) is replaced.
+//
+// A typical precedence is files < dotenv files < OS environment < arguments:
+//
+//	loader := data.NewLoader(data.WithSources(
+//		data.File("defaults.yaml"),
+//		data.File(".env"),
+//		data.Env("APP_"),
+//		data.Args(os.Args[1:]),
+//	))
+type Loader struct {
+	sources     []Source
+	ctx         Context
+	interpolate bool
+}
+
+// Option configures a Loader.
+type Option func(*Loader)
+
+// WithSources appends sources to the loader.
+func WithSources(sources ...Source) Option {
+	return func(l *Loader) {
+		l.sources = append(l.sources, sources...)
+	}
+}
+
+// WithFS reads files (and their includes) from fsys instead of the OS.
+func WithFS(fsys fs.FS) Option {
+	return func(l *Loader) {
+		l.ctx.FS = fsys
+	}
+}
+
+// WithEnviron overrides the environment used by Env sources and interpolation.
+func WithEnviron(environ []string) Option {
+	return func(l *Loader) {
+		values := make(map[string]string, len(environ))
+		for _, kv := range environ {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				values[k] = v
+			}
+		}
+		l.ctx.Environ = func() []string { return environ }
+		l.ctx.LookupEnv = func(key string) (string, bool) {
+			v, ok := values[key]
+			return v, ok
+		}
+	}
+}
+
+// WithTypeInference toggles scalar inference for string based sources. It is
+// enabled by default.
+func WithTypeInference(enabled bool) Option {
+	return func(l *Loader) {
+		l.ctx.InferTypes = enabled
+	}
+}
+
+// WithInterpolation toggles ${...} interpolation of string values after all
+// sources have been merged. It is enabled by default.
+func WithInterpolation(enabled bool) Option {
+	return func(l *Loader) {
+		l.interpolate = enabled
+	}
+}
+
+// NewLoader creates a loader reading from the OS environment and file system.
+func NewLoader(opts ...Option) *Loader {
+	l := &Loader{
+		ctx: Context{
+			LookupEnv:  os.LookupEnv,
+			Environ:    os.Environ,
+			InferTypes: true,
+		},
+		interpolate: true,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Add appends sources to the loader.
+func (l *Loader) Add(sources ...Source) *Loader {
+	l.sources = append(l.sources, sources...)
+	return l
+}
+
+// Load reads every source, merges them and resolves interpolations.
+func (l *Loader) Load() (map[string]any, error) {
+	out := make(map[string]any)
+	for _, src := range l.sources {
+		values, err := src.Load(&l.ctx)
+		if err != nil {
+			return nil, err
+		}
+		Merge(out, values)
+	}
+
+	if l.interpolate {
+		if err := interpolate(out, l.ctx.LookupEnv); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// Load is a shortcut for NewLoader(WithSources(sources...)).Load().
+func Load(sources ...Source) (map[string]any, error) {
+	return NewLoader(WithSources(sources...)).Load()
+}
+
+// Merge deep-merges src into dst. Values in src win; nested maps are merged
+// recursively.
+func Merge(dst, src map[string]any) map[string]any {
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]any); ok {
+			if dstMap, ok := dst[key].(map[string]any); ok {
+				Merge(dstMap, srcMap)
+				continue
+			}
+			dst[key] = Merge(make(map[string]any, len(srcMap)), srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+	return dst
+}
+
+// Set assigns value at a dotted path, creating intermediate maps as needed.
+func Set(dst map[string]any, path string, value any) error {
+	parts := strings.Split(path, ".")
+	node := dst
+	for i, part := range parts[:len(parts)-1] {
+		if part == "" {
+			return fmt.Errorf("invalid path %q", path)
+		}
+		next, ok := node[part]
+		if !ok {
+			child := make(map[string]any)
+			node[part] = child
+			node = child
+			continue
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot set %q: %q is not a map", path, strings.Join(parts[:i+1], "."))
+		}
+		node = child
+	}
+
+	leaf := parts[len(parts)-1]
+	if leaf == "" {
+		return fmt.Errorf("invalid path %q", path)
+	}
+	node[leaf] = value
+	return nil
+}
+
+// Lookup returns the value stored at a dotted path. Numeric segments index
+// into arrays: servers.0.host.
+func Lookup(src map[string]any, path string) (any, bool) {
+	var current any = src
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
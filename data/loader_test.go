@@ -0,0 +1,178 @@
+package data_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_MergesSourcesWithPrecedence(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults.yaml": {Data: []byte("name: app\nconfig:\n  db:\n    host: localhost\n    port: 5432\nfeatures: [a, b]\n")},
+		"override.json": {Data: []byte(`{"config": {"db": {"host": "db.internal"}}, "features": ["c"]}`)},
+		"extra.toml":    {Data: []byte("[config.cache]\nttl = \"5m\"\n")},
+		".env":          {Data: []byte("# comment\nexport NAME=from-env-file\nCONFIG__DEBUG=true\nQUOTED=\"a b\\n\"\n")},
+	}
+
+	loader := data.NewLoader(
+		data.WithFS(fsys),
+		data.WithEnviron([]string{"APP_CONFIG__DB__PORT=6543", "APP_VERSION=1.2.0", "OTHER=ignored"}),
+		data.WithSources(
+			data.File("defaults.yaml"),
+			data.File("override.json"),
+			data.File("extra.toml"),
+			data.File(".env"),
+			data.Env("APP_"),
+			data.Args([]string{"config.db.user=admin", "name=cli"}),
+		),
+	)
+
+	out, err := loader.Load()
+	require.NoError(t, err)
+
+	require.Equal(t, "cli", out["name"])
+	require.Equal(t, "1.2.0", out["version"])
+	require.Equal(t, "a b\n", out["quoted"])
+	require.Equal(t, []any{"c"}, out["features"])
+	require.NotContains(t, out, "other")
+
+	config := out["config"].(map[string]any)
+	require.Equal(t, true, config["debug"])
+	require.Equal(t, map[string]any{"ttl": "5m"}, config["cache"])
+
+	db := config["db"].(map[string]any)
+	require.Equal(t, "db.internal", db["host"])
+	require.Equal(t, int64(6543), db["port"])
+	require.Equal(t, "admin", db["user"])
+}
+
+func TestLoader_Includes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/main.yaml":        {Data: []byte("$include: [common/base.yaml, local.json]\nname: main\n")},
+		"project/common/base.yaml": {Data: []byte("$include: ../shared.toml\nname: base\nregion: eu\n")},
+		"project/shared.toml":      {Data: []byte("owner = \"platform\"\nregion = \"us\"\n")},
+		"project/local.json":       {Data: []byte(`{"debug": true}`)},
+	}
+
+	out, err := data.NewLoader(data.WithFS(fsys), data.WithSources(data.File("project/main.yaml"))).Load()
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]any{
+		"name":   "main",
+		"region": "eu",
+		"owner":  "platform",
+		"debug":  true,
+	}, out)
+}
+
+func TestLoader_IncludeCycle(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.yaml": {Data: []byte("$include: b.yaml\n")},
+		"b.yaml": {Data: []byte("$include: a.yaml\n")},
+	}
+
+	_, err := data.NewLoader(data.WithFS(fsys), data.WithSources(data.File("a.yaml"))).Load()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "include cycle")
+}
+
+func TestLoader_Interpolation(t *testing.T) {
+	loader := data.NewLoader(
+		data.WithEnviron([]string{"HOME=/home/dev", "PORT=9000"}),
+		data.WithSources(data.Map(map[string]any{
+			"host":    "localhost",
+			"port":    "${env:PORT}",
+			"url":     "http://${host}:${port}/${path:-api}",
+			"home":    "${HOME}/app",
+			"escaped": "$${host}",
+			"servers": []any{map[string]any{"name": "primary"}},
+			"primary": "${servers.0.name}",
+			"db":      map[string]any{"port": 5432},
+			"db_port": "${db.port}",
+		})),
+	)
+
+	out, err := loader.Load()
+	require.NoError(t, err)
+
+	require.Equal(t, "9000", out["port"])
+	require.Equal(t, "http://localhost:9000/api", out["url"])
+	require.Equal(t, "/home/dev/app", out["home"])
+	require.Equal(t, "${host}", out["escaped"])
+	require.Equal(t, "primary", out["primary"])
+	require.Equal(t, 5432, out["db_port"])
+}
+
+func TestLoader_InterpolationErrors(t *testing.T) {
+	_, err := data.NewLoader(
+		data.WithEnviron(nil),
+		data.WithSources(data.Map(map[string]any{"a": "${missing}"})),
+	).Load()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unresolved reference")
+
+	_, err = data.NewLoader(
+		data.WithEnviron(nil),
+		data.WithSources(data.Map(map[string]any{"a": "x${b}", "b": "y${a}"})),
+	).Load()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cycle")
+}
+
+func TestArgs_TypeInference(t *testing.T) {
+	out, err := data.Load(data.Args([]string{"enabled=false", "count=3", "ratio=0.5", "zip=01234", "name=x=y"}))
+	require.NoError(t, err)
+
+	require.Equal(t, false, out["enabled"])
+	require.Equal(t, int64(3), out["count"])
+	require.Equal(t, 0.5, out["ratio"])
+	require.Equal(t, "01234", out["zip"])
+	require.Equal(t, "x=y", out["name"])
+
+	out, err = data.NewLoader(data.WithTypeInference(false), data.WithSources(data.Args([]string{"count=3"}))).Load()
+	require.NoError(t, err)
+	require.Equal(t, "3", out["count"])
+
+	_, err = data.Load(data.Args([]string{"novalue"}))
+	require.Error(t, err)
+}
+
+func TestFile_Errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"list.yaml": {Data: []byte("- a\n- b\n")},
+		"data.txt":  {Data: []byte("x")},
+	}
+
+	_, err := data.NewLoader(data.WithFS(fsys), data.WithSources(data.File("list.yaml"))).Load()
+	require.Error(t, err)
+
+	_, err = data.NewLoader(data.WithFS(fsys), data.WithSources(data.File("data.txt"))).Load()
+	require.Error(t, err)
+
+	_, err = data.NewLoader(data.WithFS(fsys), data.WithSources(data.File("missing.yaml"))).Load()
+	require.Error(t, err)
+
+	out, err := data.NewLoader(data.WithFS(fsys), data.WithSources(data.OptionalFile("missing.yaml"))).Load()
+	require.NoError(t, err)
+	require.Empty(t, out)
+}
+
+func TestLoader_RendersWithEngine(t *testing.T) {
+	fsys := fstest.MapFS{
+		"greet.tpl": {Data: []byte("Hello {{ user.name }} from {{ app }}")},
+		"data.yaml": {Data: []byte("app: demo\nuser:\n  name: Ada\n")},
+	}
+
+	values, err := data.NewLoader(data.WithFS(fsys), data.WithSources(data.File("data.yaml"))).Load()
+	require.NoError(t, err)
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	result, err := renderer.RenderTemplate("greet", values)
+	require.NoError(t, err)
+	require.Equal(t, "Hello Ada from demo", result)
+}
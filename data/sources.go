@@ -0,0 +1,374 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// IncludeKey lists files, relative to the including file, whose values are
+// merged underneath the including file's own values.
+const IncludeKey = "$include"
+
+// Format identifies the syntax of a data file.
+type Format string
+
+const (
+	FormatAuto   Format = ""
+	FormatYAML   Format = "yaml"
+	FormatJSON   Format = "json"
+	FormatTOML   Format = "toml"
+	FormatDotEnv Format = "dotenv"
+)
+
+// DetectFormat guesses the file format from its name.
+func DetectFormat(name string) (Format, error) {
+	base := strings.ToLower(path.Base(filepath.ToSlash(name)))
+	switch {
+	case strings.HasSuffix(base, ".yaml"), strings.HasSuffix(base, ".yml"):
+		return FormatYAML, nil
+	case strings.HasSuffix(base, ".json"):
+		return FormatJSON, nil
+	case strings.HasSuffix(base, ".toml"):
+		return FormatTOML, nil
+	case base == ".env", strings.HasPrefix(base, ".env."), strings.HasSuffix(base, ".env"):
+		return FormatDotEnv, nil
+	}
+	return FormatAuto, fmt.Errorf("unable to detect data format for %s", name)
+}
+
+// File loads a YAML, JSON, TOML or dotenv file, detecting the format from the
+// file name. Structured files may include other files through IncludeKey.
+func File(name string) Source {
+	return FileAs(name, FormatAuto)
+}
+
+// FileAs loads a file using an explicit format.
+func FileAs(name string, format Format) Source {
+	return SourceFunc(func(ctx *Context) (map[string]any, error) {
+		return loadFile(ctx, name, format, nil)
+	})
+}
+
+// OptionalFile behaves like File but yields no data when the file is missing.
+func OptionalFile(name string) Source {
+	return SourceFunc(func(ctx *Context) (map[string]any, error) {
+		if _, err := ctx.ReadFile(name); err != nil {
+			return map[string]any{}, nil
+		}
+		return loadFile(ctx, name, FormatAuto, nil)
+	})
+}
+
+// Map adds static values, e.g. built-in defaults.
+func Map(values map[string]any) Source {
+	return SourceFunc(func(ctx *Context) (map[string]any, error) {
+		return Merge(make(map[string]any, len(values)), values), nil
+	})
+}
+
+// Env reads OS environment variables starting with prefix. The prefix is
+// stripped, names are lower cased and a double underscore nests values, so
+// with prefix "APP_" the variable APP_DB__PORT becomes db.port.
+func Env(prefix string) Source {
+	return SourceFunc(func(ctx *Context) (map[string]any, error) {
+		out := make(map[string]any)
+		if ctx.Environ == nil {
+			return out, nil
+		}
+		for _, kv := range ctx.Environ() {
+			key, value, ok := strings.Cut(kv, "=")
+			if !ok || !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			name := envKeyToPath(strings.TrimPrefix(key, prefix))
+			if name == "" {
+				continue
+			}
+			if err := Set(out, name, scalar(ctx, value)); err != nil {
+				return nil, fmt.Errorf("env %s: %w", key, err)
+			}
+		}
+		return out, nil
+	})
+}
+
+// Args parses key=value pairs, typically command line arguments. Keys use
+// dotted paths to nest values: config.db.port=5432.
+func Args(args []string) Source {
+	return SourceFunc(func(ctx *Context) (map[string]any, error) {
+		out := make(map[string]any)
+		for _, arg := range args {
+			key, value, ok := strings.Cut(arg, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid argument %q, expected key=value", arg)
+			}
+			if err := Set(out, key, scalar(ctx, value)); err != nil {
+				return nil, fmt.Errorf("argument %q: %w", arg, err)
+			}
+		}
+		return out, nil
+	})
+}
+
+func envKeyToPath(key string) string {
+	parts := strings.Split(strings.ToLower(key), "__")
+	for _, p := range parts {
+		if p == "" {
+			return ""
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func loadFile(ctx *Context, name string, format Format, stack []string) (map[string]any, error) {
+	for _, seen := range stack {
+		if seen == name {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, name), " -> "))
+		}
+	}
+	stack = append(stack, name)
+
+	if format == FormatAuto {
+		detected, err := DetectFormat(name)
+		if err != nil {
+			return nil, err
+		}
+		format = detected
+	}
+
+	content, err := ctx.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read data file %s: %w", name, err)
+	}
+
+	values, err := Parse(content, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if format == FormatDotEnv {
+		for key, value := range values {
+			if s, ok := value.(string); ok {
+				values[key] = scalar(ctx, s)
+			}
+		}
+		return expandDotEnvKeys(values)
+	}
+
+	includes, err := includeList(values[IncludeKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	delete(values, IncludeKey)
+
+	if len(includes) == 0 {
+		return values, nil
+	}
+
+	out := make(map[string]any)
+	for _, include := range includes {
+		included, err := loadFile(ctx, resolveInclude(ctx, name, include), FormatAuto, stack)
+		if err != nil {
+			return nil, err
+		}
+		Merge(out, included)
+	}
+	return Merge(out, values), nil
+}
+
+func expandDotEnvKeys(values map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(values))
+	for key, value := range values {
+		name := envKeyToPath(key)
+		if name == "" {
+			return nil, fmt.Errorf("invalid variable name %q", key)
+		}
+		if err := Set(out, name, value); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func includeList(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s entries must be strings", IncludeKey)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s must be a string or a list of strings", IncludeKey)
+}
+
+func resolveInclude(ctx *Context, from, include string) string {
+	if ctx.FS != nil {
+		if path.IsAbs(include) {
+			return strings.TrimPrefix(include, "/")
+		}
+		return path.Join(path.Dir(from), include)
+	}
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(from), include)
+}
+
+// Parse decodes content in the given format into a map.
+func Parse(content []byte, format Format) (map[string]any, error) {
+	out := map[string]any{}
+	switch format {
+	case FormatYAML:
+		if len(bytes.TrimSpace(content)) == 0 {
+			return out, nil
+		}
+		var node any
+		if err := yaml.Unmarshal(content, &node); err != nil {
+			return nil, fmt.Errorf("invalid yaml: %w", err)
+		}
+		if node == nil {
+			return out, nil
+		}
+		m, ok := normalise(node).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid yaml: top level value must be a mapping")
+		}
+		return m, nil
+	case FormatJSON:
+		if err := json.Unmarshal(content, &out); err != nil {
+			return nil, fmt.Errorf("invalid json: %w", err)
+		}
+		return out, nil
+	case FormatTOML:
+		if err := toml.Unmarshal(content, &out); err != nil {
+			return nil, fmt.Errorf("invalid toml: %w", err)
+		}
+		return normalise(out).(map[string]any), nil
+	case FormatDotEnv:
+		return parseDotEnv(content)
+	}
+	return nil, fmt.Errorf("unsupported data format %q", format)
+}
+
+// normalise converts decoder specific containers into map[string]any and []any.
+func normalise(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = normalise(item)
+		}
+		return v
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[fmt.Sprint(k)] = normalise(item)
+		}
+		return out
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalise(item)
+		}
+		return out
+	case []any:
+		for i, item := range v {
+			v[i] = normalise(item)
+		}
+		return v
+	}
+	return value
+}
+
+func parseDotEnv(content []byte) (map[string]any, error) {
+	out := make(map[string]any)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid dotenv line %d: %q", lineNo, line)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid dotenv line %d: %w", lineNo, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+
+		out[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var (
+	intPattern   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	floatPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)\.[0-9]+$`)
+)
+
+// scalar infers booleans, null and numbers from string values. Numbers with
+// leading zeros (zip codes, octal looking ids) stay strings.
+func scalar(ctx *Context, value string) any {
+	if !ctx.InferTypes {
+		return value
+	}
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if intPattern.MatchString(value) {
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	}
+	if floatPattern.MatchString(value) {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
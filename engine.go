@@ -10,6 +10,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 	"unicode/utf8"
 
 	"github.com/flosch/pongo2/v6"
@@ -27,6 +28,7 @@ type Engine struct {
 	mu          sync.RWMutex
 	templateSet *pongo2.TemplateSet
 	templates   map[string]*pongo2.Template
+	loading     map[string]*templateLoad
 	tplExt      string
 	fs          fs.FS
 	baseDir     string
@@ -35,6 +37,11 @@ type Engine struct {
 	globals     map[string]any
 	globalData  map[string]any
 	hooks       *HookManager
+	frontMatter bool
+	fmLoaders   []*frontMatterLoader
+	clock       Clock
+
+	interceptors []Interceptor
 }
 
 type Option func(*Engine)
@@ -76,11 +83,11 @@ func WithTemplateFunc(funcs map[string]any) Option {
 
 		for name, fn := range funcs {
 			if filter, ok := asFilter(fn); ok {
-				e.filters[name] = filter
+				e.filters[name] = recoverableFilter(name, filter)
 				continue
 			}
 
-			e.globals[name] = fn
+			e.globals[name] = recoverableFunc(name, fn)
 		}
 
 		e.applyTemplateFuncsLocked()
@@ -127,16 +134,28 @@ func WithExtension(ext string) Option {
 	}
 }
 
+// WithFrontMatter toggles front matter parsing. It is enabled by default; when
+// disabled templates are parsed verbatim and Metadata always returns nil.
+func WithFrontMatter(enabled bool) Option {
+	return func(e *Engine) {
+		e.frontMatter = enabled
+	}
+}
+
 func NewRenderer(opts ...Option) (*Engine, error) {
 	e := &Engine{
-		templates:  make(map[string]*pongo2.Template),
-		tplExt:     ".tpl",
-		funcMap:    defaultFuncMaps(),
-		filters:    make(map[string]pongo2.FilterFunction),
-		globals:    make(map[string]any),
-		globalData: make(map[string]any),
-		hooks:      NewHooksManager(),
-	}
+		templates:   make(map[string]*pongo2.Template),
+		loading:     make(map[string]*templateLoad),
+		tplExt:      ".tpl",
+		funcMap:     defaultFuncMaps(),
+		filters:     make(map[string]pongo2.FilterFunction),
+		globals:     make(map[string]any),
+		globalData:  make(map[string]any),
+		hooks:       NewHooksManager(),
+		frontMatter: true,
+		clock:       DefaultClock(),
+	}
+	e.globals["now"] = e.now
 
 	for _, opt := range opts {
 		opt(e)
@@ -168,10 +187,20 @@ func (r *Engine) Load() error {
 		loaders = append(loaders, loader)
 	}
 
+	var fmLoaders []*frontMatterLoader
+	if r.frontMatter {
+		for i, l := range loaders {
+			fml := newFrontMatterLoader(l)
+			fmLoaders = append(fmLoaders, fml)
+			loaders[i] = fml
+		}
+	}
+
 	ts := pongo2.NewSet("default", loaders...)
 
 	r.mu.Lock()
 	r.templateSet = ts
+	r.fmLoaders = fmLoaders
 	r.mu.Unlock()
 
 	// then we apply global data
@@ -271,13 +300,13 @@ func (r *Engine) applyTemplateFuncsLocked() {
 
 		if filter, ok := asFilter(fn); ok {
 			if _, exists := r.filters[name]; !exists {
-				r.filters[name] = filter
+				r.filters[name] = recoverableFilter(name, filter)
 			}
 			continue
 		}
 
 		if _, exists := r.globals[name]; !exists {
-			r.globals[name] = fn
+			r.globals[name] = recoverableFunc(name, fn)
 		}
 	}
 
@@ -335,78 +364,188 @@ func (r *Engine) RegisterFilter(name string, fn func(input any, param any) (any,
 // by marshaling it to JSON and then unmarshaling. Be aware of the performance
 // implications and that this respects `json` struct tags.
 func (r *Engine) RenderString(templateContent string, data any, out ...io.Writer) (string, error) {
+	return r.renderString(templateContent, data, nil, out)
+}
+
+// renderString renders templateContent, recording hook runs in trace when set.
+func (r *Engine) renderString(templateContent string, data any, trace *RenderTrace, out []io.Writer) (string, error) {
+	start := time.Now()
+	if trace != nil {
+		defer func() { trace.Duration = time.Since(start) }()
+	}
 
 	sharedMeta := make(map[string]any)
+	if trace != nil {
+		sharedMeta[MetadataHookTraceKey] = trace
+	}
+
+	if meta, _, err := r.parseFrontMatter(templateContent); err == nil && meta != nil {
+		sharedMeta[MetadataFrontMatterKey] = meta
+	}
+
+	req := &RenderRequest{Template: templateContent, Data: data, Metadata: sharedMeta}
+	output, err := r.intercept(func(req *RenderRequest) (string, error) {
+		ctx := &HookContext{
+			Data:     req.Data,
+			Metadata: req.Metadata,
+			Template: req.Template,
+			Clock:    r.clock,
+		}
+		output, err := r.executeString(ctx)
+		req.Data, req.Template = ctx.Data, ctx.Template
+		return output, err
+	})(req)
+
+	ctx := &HookContext{
+		Data:     req.Data,
+		Metadata: req.Metadata,
+		Template: req.Template,
+		Output:   output,
+		Clock:    r.clock,
+	}
+	return r.finishRender(ctx, err, start, out)
+}
+
+// executeString runs the pre hooks, template and post hooks of a string
+// render, keeping ctx up to date with the data and template hooks set.
+func (r *Engine) executeString(ctx *HookContext) (string, error) {
+	data, templateContent, sharedMeta := ctx.Data, ctx.Template, ctx.Metadata
+	defer func() {
+		ctx.Data, ctx.Template = data, templateContent
+	}()
 
 	// execute pre hooks
-	for _, hook := range r.hooks.PreHooks() {
+	for _, hook := range r.preHooks(nil) {
 		pctx := &HookContext{
 			Data:      data,
 			Metadata:  sharedMeta,
 			Template:  templateContent,
 			IsPreHook: true,
+			Clock:     r.clock,
 		}
-		if err := hook(pctx); err != nil {
-			return "", fmt.Errorf("pre-hook failed: %w", err)
+		if !hook.applies(pctx) {
+			continue
+		}
+		run := traceFrom(sharedMeta).start(hook.label, HookKindPre, pctx)
+		err := hook.run(pctx)
+		run.end(pctx, "", err)
+		if err != nil {
+			return "", renderError(PhasePreHook, "", fmt.Errorf("pre-hook failed: %w", err))
 		}
 		data = pctx.Data
 		templateContent = pctx.Template
 	}
 
+	meta, body, err := r.parseFrontMatter(templateContent)
+	if err != nil {
+		return "", renderError(PhaseLoad, "", fmt.Errorf("failed to parse template string: %w", err))
+	}
+	setFrontMatter(sharedMeta, meta)
+
+	if data, err = meta.prepareData(data); err != nil {
+		return "", renderError(PhaseData, "", fmt.Errorf("failed to prepare template data: %w", err))
+	}
+
 	// Create template from string content
-	tmpl, err := r.templateSet.FromString(templateContent)
+	tmpl, err := r.templateSet.FromBytes(meta.applyEscape(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template string: %w", err)
+		return "", renderError(PhaseLoad, "", fmt.Errorf("failed to parse template string: %w", err))
+	}
+
+	if err := r.runLoadHooks(&HookContext{Template: templateContent, Metadata: sharedMeta, Clock: r.clock}); err != nil {
+		return "", renderError(PhaseLoad, "", err)
 	}
 
 	viewContext, err := ConvertToContext(data)
 	if err != nil {
-		return "", fmt.Errorf("failed to convert data to context: %w", err)
+		return "", renderError(PhaseData, "", fmt.Errorf("failed to convert data to context: %w", err))
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.ExecuteWriter(viewContext, &buf); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+	if err := executeTemplate(tmpl, "", viewContext, &buf); err != nil {
+		return "", renderError(PhaseExecute, "", fmt.Errorf("failed to execute template: %w", err))
 	}
 
 	renderedStr := buf.String()
 
 	// execute post hooks
-	for _, hook := range r.hooks.PostHooks() {
+	for _, hook := range r.postHooks(nil) {
 		pctx := &HookContext{
 			Data:     data,
 			Metadata: sharedMeta,
 			Template: templateContent,
 			Output:   renderedStr,
+			Clock:    r.clock,
+		}
+		if !hook.applies(pctx) {
+			continue
 		}
-		modifiedOutput, err := hook(pctx)
+		run := traceFrom(sharedMeta).start(hook.label, HookKindPost, pctx)
+		modifiedOutput, err := hook.run(pctx)
+		run.end(pctx, modifiedOutput, err)
 		if err != nil {
-			return "", fmt.Errorf("post-hook failed: %w", err)
+			return "", renderError(PhasePostHook, "", fmt.Errorf("post-hook failed: %w", err))
 		}
 		data = pctx.Data
 		renderedStr = modifiedOutput
 	}
 
-	// Write to provided writers
-	if len(out) > 0 {
-		for _, w := range out {
-			if _, err := w.Write([]byte(renderedStr)); err != nil {
-				return "", err
-			}
-		}
-	}
-
 	return renderedStr, nil
 }
 
-// RegisterPreHook registers a pre-generation hook
-func (e *Engine) RegisterPreHook(hook PreHook) {
-	e.hooks.AddPreHook(hook)
+// RegisterPreHook registers a pre-generation hook with an optional priority,
+// see HookManager.AddPreHook.
+func (e *Engine) RegisterPreHook(hook PreHook, priority ...int) {
+	e.hooks.AddPreHook(hook, priority...)
 }
 
-// RegisterPostHook registers a post-generation hook
-func (e *Engine) RegisterPostHook(hook PostHook) {
-	e.hooks.AddPostHook(hook)
+// RegisterPostHook registers a post-generation hook with an optional priority,
+// see HookManager.AddPostHook.
+func (e *Engine) RegisterPostHook(hook PostHook, priority ...int) {
+	e.hooks.AddPostHook(hook, priority...)
+}
+
+// RegisterNamedPreHook registers a pre-generation hook under name, see
+// HookManager.AddNamedPreHook.
+func (e *Engine) RegisterNamedPreHook(name string, hook PreHook, opts ...HookOption) error {
+	return e.hooks.AddNamedPreHook(name, hook, opts...)
+}
+
+// RegisterNamedPostHook registers a post-generation hook under name, see
+// HookManager.AddNamedPostHook.
+func (e *Engine) RegisterNamedPostHook(name string, hook PostHook, opts ...HookOption) error {
+	return e.hooks.AddNamedPostHook(name, hook, opts...)
+}
+
+// RemoveHook unregisters the hook registered under name, reporting whether it
+// existed.
+func (e *Engine) RemoveHook(name string) bool {
+	return e.hooks.Remove(name)
+}
+
+// ReplacePreHook swaps the pre hook registered under name.
+func (e *Engine) ReplacePreHook(name string, hook PreHook) error {
+	return e.hooks.ReplacePreHook(name, hook)
+}
+
+// ReplacePostHook swaps the post hook registered under name.
+func (e *Engine) ReplacePostHook(name string, hook PostHook) error {
+	return e.hooks.ReplacePostHook(name, hook)
+}
+
+// SetHookEnabled enables or disables the hook registered under name.
+func (e *Engine) SetHookEnabled(name string, enabled bool) error {
+	return e.hooks.SetEnabled(name, enabled)
+}
+
+// HookEnabled reports whether a hook is registered under name and enabled.
+func (e *Engine) HookEnabled(name string) bool {
+	return e.hooks.Enabled(name)
+}
+
+// Hooks describes the registered hooks in execution order.
+func (e *Engine) Hooks() []HookInfo {
+	return e.hooks.List()
 }
 
 // Render intelligently renders either a template file or template string content.
@@ -438,93 +577,319 @@ func isTemplateContent(s string) bool {
 // by marshaling it to JSON and then unmarshaling. Be aware of the performance
 // implications and that this respects `json` struct tags.
 func (r *Engine) RenderTemplate(name string, data any, out ...io.Writer) (string, error) {
+	req := &fileRequest{name: name, data: data}
+	return r.renderFile(req, out...)
+}
+
+// fileRequest carries the state of a single template file render. Hooks may
+// update the template name, data and output path, which are reflected back
+// into the request.
+type fileRequest struct {
+	name       string
+	data       any
+	outputPath string
+	preHooks   []PreHook
+	postHooks  []PostHook
+	trace      *RenderTrace
+	// renderPath renders outputPath as a path template against the prepared
+	// data, once pre hooks and front matter defaults applied. skipEmpty lets a
+	// segment rendering empty skip the render, reported by skipped.
+	renderPath bool
+	skipEmpty  bool
+	skipped    bool
+}
+
+func (r *Engine) renderFile(req *fileRequest, out ...io.Writer) (string, error) {
+	start := time.Now()
+
+	if req.trace != nil {
+		defer func() { req.trace.Duration = time.Since(start) }()
+	}
 
 	sharedMeta := make(map[string]any)
 	sharedMeta["ext"] = r.tplExt
+	if req.trace != nil {
+		sharedMeta[MetadataHookTraceKey] = req.trace
+	}
+
+	rreq := &RenderRequest{
+		TemplateName: req.name,
+		Data:         req.data,
+		OutputPath:   req.outputPath,
+		Metadata:     sharedMeta,
+	}
+	output, err := r.intercept(func(rreq *RenderRequest) (string, error) {
+		req.name, req.data, req.outputPath = rreq.TemplateName, rreq.Data, rreq.OutputPath
+		output, err := r.executeFile(req, rreq.Metadata)
+		rreq.TemplateName, rreq.Data, rreq.OutputPath = req.name, req.data, req.outputPath
+		return output, err
+	})(rreq)
+	req.name, req.data, req.outputPath = rreq.TemplateName, rreq.Data, rreq.OutputPath
+	if err == nil && req.skipped {
+		return "", nil
+	}
+
+	ctx := &HookContext{
+		TemplateName: req.name,
+		Data:         req.data,
+		Metadata:     rreq.Metadata,
+		OutputPath:   req.outputPath,
+		Output:       output,
+		Clock:        r.clock,
+	}
+	return r.finishRender(ctx, err, start, out)
+}
+
+// executeFile runs the pre hooks, template and post hooks of a file render.
+func (r *Engine) executeFile(req *fileRequest, sharedMeta map[string]any) (string, error) {
+	name, data := req.name, req.data
+	defer func() {
+		req.name, req.data = name, data
+	}()
+
+	// pre hooks may still change the template, so only peek at its front
+	// matter here; compiling it, and running load hooks, is left to getTemplate
+	if meta := r.peekMetadata(r.templatePath(name)); meta != nil {
+		sharedMeta[MetadataFrontMatterKey] = meta
+	}
 
 	// execute pre hooks
-	for _, hook := range r.hooks.PreHooks() {
+	for _, hook := range r.preHooks(req.preHooks) {
 		pctx := &HookContext{
 			Data:         data,
 			Metadata:     sharedMeta,
 			TemplateName: name,
+			OutputPath:   req.outputPath,
 			IsPreHook:    true,
+			Clock:        r.clock,
+		}
+		if !hook.applies(pctx) {
+			continue
 		}
-		if err := hook(pctx); err != nil {
-			return "", fmt.Errorf("pre-hook failed: %w", err)
+		run := traceFrom(sharedMeta).start(hook.label, HookKindPre, pctx)
+		err := hook.run(pctx)
+		run.end(pctx, "", err)
+		if err != nil {
+			return "", renderError(PhasePreHook, name, fmt.Errorf("pre-hook failed: %w", err))
 		}
 		data = pctx.Data
 		name = pctx.TemplateName
+		req.outputPath = pctx.OutputPath
 	}
 
-	templatePath := name
-	if !strings.HasSuffix(templatePath, r.tplExt) {
-		templatePath += r.tplExt
-	}
+	templatePath := r.templatePath(name)
 
 	tmpl, err := r.getTemplate(templatePath)
 	if err != nil {
-		return "", err
+		return "", renderError(PhaseLoad, name, err)
+	}
+
+	meta := r.lookupMetadata(templatePath)
+	setFrontMatter(sharedMeta, meta)
+
+	if data, err = meta.prepareData(data); err != nil {
+		return "", renderError(PhaseData, name, fmt.Errorf("failed to prepare data for template %s: %w", templatePath, err))
 	}
 
 	viewContext, err := ConvertToContext(data)
 	if err != nil {
-		return "", fmt.Errorf("failed to convert data to context: %w", err)
+		return "", renderError(PhaseData, name, fmt.Errorf("failed to convert data to context: %w", err))
+	}
+
+	if req.renderPath {
+		outputPath, empty, err := r.renderPath(req.outputPath, viewContext)
+		if err != nil {
+			return "", renderError(PhaseData, name, fmt.Errorf("failed to render output path %s: %w", req.outputPath, err))
+		}
+		if empty {
+			if !req.skipEmpty && (meta == nil || !meta.SkipEmptyPath) {
+				return "", renderError(PhaseData, name, fmt.Errorf("output path %s has a segment rendering empty", req.outputPath))
+			}
+			req.skipped = true
+			return "", nil
+		}
+		req.outputPath = outputPath
 	}
 
 	var buf bytes.Buffer
-	if err := tmpl.ExecuteWriter(viewContext, &buf); err != nil {
-		return "", fmt.Errorf("failed to execute template %s: %w", templatePath, err)
+	if err := executeTemplate(tmpl, name, viewContext, &buf); err != nil {
+		return "", renderError(PhaseExecute, name, fmt.Errorf("failed to execute template %s: %w", templatePath, err))
 	}
 
 	renderedStr := buf.String()
 
 	// execute post hooks
-	for _, hook := range r.hooks.PostHooks() {
+	for _, hook := range r.postHooks(req.postHooks) {
 		pctx := &HookContext{
 			Data:         data,
 			Metadata:     sharedMeta,
 			TemplateName: name,
+			OutputPath:   req.outputPath,
 			Output:       renderedStr,
+			Clock:        r.clock,
+		}
+		if !hook.applies(pctx) {
+			continue
 		}
-		modifiedOutput, err := hook(pctx)
+		run := traceFrom(sharedMeta).start(hook.label, HookKindPost, pctx)
+		modifiedOutput, err := hook.run(pctx)
+		run.end(pctx, modifiedOutput, err)
 		if err != nil {
-			return "", fmt.Errorf("post-hook failed: %w", err)
+			return "", renderError(PhasePostHook, name, fmt.Errorf("post-hook failed: %w", err))
 		}
 		data = pctx.Data
 		renderedStr = modifiedOutput
 	}
 
-	if len(out) > 0 {
-		for _, w := range out {
-			if _, err := w.Write([]byte(renderedStr)); err != nil {
-				return "", err
-			}
-		}
+	return renderedStr, nil
+}
+
+// templatePath returns the path of the template `name` in the engine file
+// system, adding the template extension when missing.
+func (r *Engine) templatePath(name string) string {
+	if strings.HasSuffix(name, r.tplExt) {
+		return name
 	}
+	return name + r.tplExt
+}
 
-	return renderedStr, nil
+// Extension returns the template file extension, including the leading dot.
+func (r *Engine) Extension() string {
+	return r.tplExt
 }
 
-func (r *Engine) getTemplate(path string) (*pongo2.Template, error) {
+// Metadata returns the front matter metadata declared by the template `name`.
+// The template is loaded (and cached) if needed. A nil result with a nil error
+// means the template has no front matter block.
+func (r *Engine) Metadata(name string) (*TemplateMetadata, error) {
+	templatePath := r.templatePath(name)
+
+	if _, err := r.getTemplate(templatePath); err != nil {
+		return nil, err
+	}
+
+	return r.lookupMetadata(templatePath), nil
+}
+
+func (r *Engine) lookupMetadata(path string) *TemplateMetadata {
 	r.mu.RLock()
-	if tmpl, ok := r.templates[path]; ok {
-		r.mu.RUnlock()
-		return tmpl, nil
+	loaders := r.fmLoaders
+	r.mu.RUnlock()
+
+	for _, l := range loaders {
+		if meta, ok := l.lookup(path); ok {
+			return meta
+		}
+	}
+
+	return nil
+}
+
+// peekMetadata returns the front matter of the template at path without
+// compiling it. It returns nil when the template has no front matter or cannot
+// be read, leaving the error to the render itself.
+func (r *Engine) peekMetadata(path string) *TemplateMetadata {
+	if !r.frontMatter {
+		return nil
 	}
+
+	r.mu.RLock()
+	loaders := r.fmLoaders
 	r.mu.RUnlock()
-	r.mu.Lock()
-	defer r.mu.Unlock()
 
+	for _, l := range loaders {
+		if meta, ok := l.lookup(path); ok {
+			return meta
+		}
+	}
+
+	fsys, err := r.SourceFS()
+	if err != nil {
+		return nil
+	}
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil
+	}
+	meta, _, err := ParseFrontMatter(content)
+	if err != nil {
+		return nil
+	}
+	return meta
+}
+
+func (r *Engine) parseFrontMatter(content string) (*TemplateMetadata, []byte, error) {
+	if !r.frontMatter {
+		return nil, []byte(content), nil
+	}
+	return ParseFrontMatter([]byte(content))
+}
+
+func setFrontMatter(meta map[string]any, fm *TemplateMetadata) {
+	if fm == nil {
+		delete(meta, MetadataFrontMatterKey)
+		return
+	}
+	meta[MetadataFrontMatterKey] = fm
+}
+
+func (r *Engine) getTemplate(path string) (*pongo2.Template, error) {
+	r.mu.Lock()
 	if tmpl, ok := r.templates[path]; ok {
+		r.mu.Unlock()
 		return tmpl, nil
 	}
+	if load, ok := r.loading[path]; ok {
+		r.mu.Unlock()
+		<-load.done
+		return load.tmpl, load.err
+	}
+	load := &templateLoad{done: make(chan struct{})}
+	r.loading[path] = load
+	r.mu.Unlock()
+
+	load.tmpl, load.err = r.loadTemplate(path)
+
+	r.mu.Lock()
+	delete(r.loading, path)
+	if load.err == nil {
+		r.templates[path] = load.tmpl
+	}
+	r.mu.Unlock()
+	close(load.done)
 
+	return load.tmpl, load.err
+}
+
+// templateLoad is a template being compiled. Concurrent callers wait for it
+// rather than compiling the template and running load hooks a second time.
+type templateLoad struct {
+	done chan struct{}
+	tmpl *pongo2.Template
+	err  error
+}
+
+// loadTemplate compiles the template at path and runs the load hooks. The
+// caller caches the template only when both succeed, so a template rejected
+// by a load hook fails every render rather than just the first one.
+func (r *Engine) loadTemplate(path string) (*pongo2.Template, error) {
+	r.mu.Lock()
 	compiled, err := r.templateSet.FromFile(path)
+	r.mu.Unlock()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load template %s: %w", path, err)
 	}
-	r.templates[path] = compiled
+
+	meta := map[string]any{"ext": r.tplExt}
+	setFrontMatter(meta, r.lookupMetadata(path))
+	if err := r.runLoadHooks(&HookContext{
+		TemplateName: strings.TrimSuffix(path, r.tplExt),
+		Metadata:     meta,
+		Clock:        r.clock,
+	}); err != nil {
+		return nil, err
+	}
+
 	return compiled, nil
 }
 
@@ -0,0 +1,325 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/flosch/pongo2/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataFrontMatterKey is the HookContext.Metadata key holding the
+// *TemplateMetadata parsed from the rendered template's front matter.
+const MetadataFrontMatterKey = "front_matter"
+
+// FrontMatterFormat identifies the syntax used by a front matter block.
+type FrontMatterFormat string
+
+const (
+	FrontMatterNone FrontMatterFormat = ""
+	FrontMatterYAML FrontMatterFormat = "yaml"
+	FrontMatterTOML FrontMatterFormat = "toml"
+	FrontMatterJSON FrontMatterFormat = "json"
+)
+
+// Escape modes understood by TemplateMetadata.Escape.
+const (
+	EscapeDefault = ""
+	EscapeNone    = "none"
+	EscapeHTML    = "html"
+)
+
+// TemplateMetadata describes a template through its front matter block.
+//
+// A template may start with a YAML (`---`), TOML (`+++`) or JSON (`{` / `}`)
+// block. The block is stripped before the template is parsed and the known
+// keys are decoded into this struct; anything else is kept in Extra.
+type TemplateMetadata struct {
+	Description string         `json:"description,omitempty"`
+	Required    []string       `json:"required,omitempty"`
+	Defaults    map[string]any `json:"defaults,omitempty"`
+	Output      string         `json:"output,omitempty"`
+	Escape      string         `json:"escape,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	WritePolicy WritePolicy    `json:"write_policy,omitempty"`
+	// SkipEmptyPath lets GenerateTree skip the template when a segment of its
+	// output path renders empty, instead of failing.
+	SkipEmptyPath bool              `json:"skip_empty_path,omitempty"`
+	Extra         map[string]any    `json:"-"`
+	Format        FrontMatterFormat `json:"-"`
+}
+
+var frontMatterKeys = []string{"description", "required", "defaults", "output", "escape", "tags", "write_policy", "skip_empty_path"}
+
+// HasTag reports whether the metadata lists the given tag.
+func (m *TemplateMetadata) HasTag(tag string) bool {
+	if m == nil {
+		return false
+	}
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKnownKey(raw map[string]any) bool {
+	for _, key := range frontMatterKeys {
+		if _, ok := raw[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFrontMatter splits a template into its front matter metadata and body.
+// When the content has no front matter block the returned metadata is nil and
+// body is the original content.
+//
+// YAML and TOML blocks must use at least one known key, so outputs starting
+// with a `---` document marker, multi-document YAML included, or with a `+++`
+// block of their own keep working. YAML blocks must also decode into a mapping.
+// JSON blocks must use a known key too and be followed by template content.
+func ParseFrontMatter(content []byte) (*TemplateMetadata, []byte, error) {
+	format, block, body, ok := splitFrontMatter(content)
+	if !ok {
+		return nil, content, nil
+	}
+
+	raw := map[string]any{}
+	switch format {
+	case FrontMatterYAML:
+		var node any
+		if err := yaml.Unmarshal(block, &node); err != nil {
+			return nil, content, nil
+		}
+		// multi-document YAML outputs start with a `---` mapping too, so only
+		// blocks using a known key count as front matter
+		m, ok := node.(map[string]any)
+		if !ok || !hasKnownKey(m) {
+			return nil, content, nil
+		}
+		raw = m
+	case FrontMatterTOML:
+		// Hugo style outputs start with a `+++` block of their own
+		if err := toml.Unmarshal(block, &raw); err != nil {
+			return nil, content, fmt.Errorf("invalid toml front matter: %w", err)
+		}
+		if !hasKnownKey(raw) {
+			return nil, content, nil
+		}
+	case FrontMatterJSON:
+		// a leading `{` line is also how JSON outputs start, so only blocks that
+		// parse, use a known key and are followed by a body count as front matter
+		if err := json.Unmarshal(block, &raw); err != nil || !hasKnownKey(raw) || len(bytes.TrimSpace(body)) == 0 {
+			return nil, content, nil
+		}
+	}
+
+	meta, err := decodeMetadata(raw)
+	if err != nil {
+		return nil, content, err
+	}
+	meta.Format = format
+
+	return meta, body, nil
+}
+
+func decodeMetadata(raw map[string]any) (*TemplateMetadata, error) {
+	known := make(map[string]any, len(frontMatterKeys))
+	for _, key := range frontMatterKeys {
+		if v, ok := raw[key]; ok {
+			known[key] = v
+		}
+	}
+
+	b, err := json.Marshal(known)
+	if err != nil {
+		return nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	meta := &TemplateMetadata{}
+	if err := json.Unmarshal(b, meta); err != nil {
+		return nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	switch strings.ToLower(meta.Escape) {
+	case EscapeDefault, EscapeNone, EscapeHTML:
+		meta.Escape = strings.ToLower(meta.Escape)
+	default:
+		return nil, fmt.Errorf("invalid front matter: unknown escape mode %q", meta.Escape)
+	}
+
+	if meta.WritePolicy != "" {
+		if meta.WritePolicy, err = ParseWritePolicy(string(meta.WritePolicy)); err != nil {
+			return nil, fmt.Errorf("invalid front matter: %w", err)
+		}
+	}
+
+	for key, value := range raw {
+		if _, ok := known[key]; ok {
+			continue
+		}
+		if meta.Extra == nil {
+			meta.Extra = make(map[string]any)
+		}
+		meta.Extra[key] = value
+	}
+
+	return meta, nil
+}
+
+func splitFrontMatter(content []byte) (FrontMatterFormat, []byte, []byte, bool) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	first, rest, found := cutLine(content)
+	if !found {
+		return FrontMatterNone, nil, nil, false
+	}
+
+	var format FrontMatterFormat
+	var closing string
+	switch strings.TrimRight(string(first), " \t\r") {
+	case "---":
+		format, closing = FrontMatterYAML, "---"
+	case "+++":
+		format, closing = FrontMatterTOML, "+++"
+	case "{":
+		format, closing = FrontMatterJSON, "}"
+	default:
+		return FrontMatterNone, nil, nil, false
+	}
+
+	offset := 0
+	for offset <= len(rest) {
+		line, next, more := cutLine(rest[offset:])
+		if strings.TrimRight(string(line), " \t\r") == closing {
+			block := rest[:offset]
+			body := next
+			if format == FrontMatterJSON {
+				block = append(append([]byte("{\n"), block...), '}')
+			}
+			return format, block, body, true
+		}
+		if !more {
+			break
+		}
+		offset += len(line) + 1
+	}
+
+	return FrontMatterNone, nil, nil, false
+}
+
+func cutLine(b []byte) (line, rest []byte, found bool) {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		return b[:i], b[i+1:], true
+	}
+	return b, nil, false
+}
+
+// applyEscape wraps the template body so the front matter escape mode is honoured.
+// Templates that extend a parent are left untouched since {% extends %} must be
+// the first tag in a template.
+func (m *TemplateMetadata) applyEscape(body []byte) []byte {
+	if m == nil || m.Escape == EscapeDefault || bytes.Contains(body, []byte("{% extends")) {
+		return body
+	}
+
+	mode := "on"
+	if m.Escape == EscapeNone {
+		mode = "off"
+	}
+
+	out := make([]byte, 0, len(body)+64)
+	out = append(out, "{% autoescape "+mode+" %}"...)
+	out = append(out, body...)
+	out = append(out, "{% endautoescape %}"...)
+	return out
+}
+
+// prepareData merges the front matter defaults into data and checks that all
+// required variables are present.
+func (m *TemplateMetadata) prepareData(data any) (any, error) {
+	if m == nil || (len(m.Defaults) == 0 && len(m.Required) == 0) {
+		return data, nil
+	}
+
+	ctx, err := ConvertToContext(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert data to context: %w", err)
+	}
+
+	for key, value := range m.Defaults {
+		if _, exists := ctx[key]; !exists {
+			ctx[key] = value
+		}
+	}
+
+	var missing []string
+	for _, key := range m.Required {
+		if _, exists := ctx[key]; !exists {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	}
+
+	return map[string]any(ctx), nil
+}
+
+// frontMatterLoader strips front matter from templates served by the wrapped
+// loader and remembers the parsed metadata by resolved path. Templates without
+// front matter are recorded with a nil entry so lookups know which loader served them.
+type frontMatterLoader struct {
+	pongo2.TemplateLoader
+	mu   sync.RWMutex
+	meta map[string]*TemplateMetadata
+}
+
+func newFrontMatterLoader(loader pongo2.TemplateLoader) *frontMatterLoader {
+	return &frontMatterLoader{
+		TemplateLoader: loader,
+		meta:           make(map[string]*TemplateMetadata),
+	}
+}
+
+func (l *frontMatterLoader) Get(path string) (io.Reader, error) {
+	rd, err := l.TemplateLoader.Get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, body, err := ParseFrontMatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	l.mu.Lock()
+	l.meta[path] = meta
+	l.mu.Unlock()
+
+	return bytes.NewReader(meta.applyEscape(body)), nil
+}
+
+func (l *frontMatterLoader) lookup(name string) (*TemplateMetadata, bool) {
+	path := l.Abs("", name)
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	meta, ok := l.meta[path]
+	return meta, ok
+}
@@ -0,0 +1,220 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestParseFrontMatter_Formats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		format  template.FrontMatterFormat
+	}{
+		{
+			name:    "yaml",
+			content: "---\ndescription: Greeting\nrequired: [name]\ndefaults:\n  greeting: Hello\ntags: [demo]\nowner: team-a\n---\n{{ greeting }}, {{ name }}!",
+			format:  template.FrontMatterYAML,
+		},
+		{
+			name:    "toml",
+			content: "+++\ndescription = \"Greeting\"\nrequired = [\"name\"]\ntags = [\"demo\"]\nowner = \"team-a\"\n[defaults]\ngreeting = \"Hello\"\n+++\n{{ greeting }}, {{ name }}!",
+			format:  template.FrontMatterTOML,
+		},
+		{
+			name:    "json",
+			content: "{\n\"description\": \"Greeting\", \"required\": [\"name\"], \"defaults\": {\"greeting\": \"Hello\"}, \"tags\": [\"demo\"], \"owner\": \"team-a\"\n}\n{{ greeting }}, {{ name }}!",
+			format:  template.FrontMatterJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := template.ParseFrontMatter([]byte(tt.content))
+			require.NoError(t, err)
+			require.NotNil(t, meta)
+
+			require.Equal(t, tt.format, meta.Format)
+			require.Equal(t, "Greeting", meta.Description)
+			require.Equal(t, []string{"name"}, meta.Required)
+			require.Equal(t, "Hello", meta.Defaults["greeting"])
+			require.True(t, meta.HasTag("demo"))
+			require.Equal(t, "team-a", meta.Extra["owner"])
+			require.Equal(t, "{{ greeting }}, {{ name }}!", string(body))
+		})
+	}
+}
+
+func TestParseFrontMatter_NotFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "plain template", content: "Hello {{ name }}"},
+		{name: "unterminated", content: "---\nname: test\n"},
+		{name: "yaml document marker", content: "---\n- {{ item }}\n---\n- other\n"},
+		{name: "toml without known keys", content: "+++\ntitle = \"x\"\n+++\nbody"},
+		{name: "multi-document yaml", content: "---\napiVersion: v1\nkind: Service\n---\napiVersion: apps/v1\nkind: Deployment\n"},
+		{name: "json output", content: "{\n  \"name\": \"{{ name }}\"\n}\n"},
+		{name: "json without known keys", content: "{\n\"name\": \"x\"\n}\nbody"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := template.ParseFrontMatter([]byte(tt.content))
+			require.NoError(t, err)
+			require.Nil(t, meta)
+			require.Equal(t, tt.content, string(body))
+		})
+	}
+}
+
+func TestParseFrontMatter_InvalidEscape(t *testing.T) {
+	_, _, err := template.ParseFrontMatter([]byte("---\nescape: latex\n---\nbody"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown escape mode")
+
+	_, _, err = template.ParseFrontMatter([]byte("---\nwrite_policy: sometimes\n---\nbody"))
+	require.ErrorContains(t, err, `unknown write policy "sometimes"`)
+}
+
+func TestEngine_FrontMatter_DefaultsAndRequired(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"greet.tpl": "---\ndescription: Greeting\nrequired: [name]\ndefaults:\n  greeting: Hello\n---\n{{ greeting }}, {{ name }}!",
+	})
+
+	renderer, err := template.NewRenderer(template.WithBaseDir(dir))
+	require.NoError(t, err)
+
+	result, err := renderer.RenderTemplate("greet", map[string]any{"name": "Alice"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello, Alice!", result)
+
+	result, err = renderer.RenderTemplate("greet", map[string]any{"name": "Bob", "greeting": "Hi"})
+	require.NoError(t, err)
+	require.Equal(t, "Hi, Bob!", result)
+
+	_, err = renderer.RenderTemplate("greet", map[string]any{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "missing required variables: name")
+}
+
+func TestEngine_Metadata(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"model.go.tpl": "---\ndescription: Model file\noutput: \"{{ package_name }}/model.go\"\ntags: [go, model]\n---\npackage {{ package_name }}\n",
+		"plain.tpl":    "no front matter",
+	})
+
+	renderer, err := template.NewRenderer(template.WithBaseDir(dir))
+	require.NoError(t, err)
+
+	meta, err := renderer.Metadata("model.go")
+	require.NoError(t, err)
+	require.NotNil(t, meta)
+	require.Equal(t, "Model file", meta.Description)
+	require.Equal(t, "{{ package_name }}/model.go", meta.Output)
+	require.Equal(t, []string{"go", "model"}, meta.Tags)
+
+	meta, err = renderer.Metadata("plain")
+	require.NoError(t, err)
+	require.Nil(t, meta)
+
+	_, err = renderer.Metadata("missing")
+	require.Error(t, err)
+}
+
+func TestEngine_FrontMatter_HookContext(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"tagged.tpl": "---\ntags: [internal]\n---\nbody",
+	})
+
+	renderer, err := template.NewRenderer(template.WithBaseDir(dir))
+	require.NoError(t, err)
+
+	var preMeta, postMeta *template.TemplateMetadata
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		preMeta, _ = ctx.Metadata[template.MetadataFrontMatterKey].(*template.TemplateMetadata)
+		return nil
+	})
+	renderer.RegisterPostHook(func(ctx *template.HookContext) (string, error) {
+		postMeta, _ = ctx.Metadata[template.MetadataFrontMatterKey].(*template.TemplateMetadata)
+		return ctx.Output, nil
+	})
+
+	result, err := renderer.RenderTemplate("tagged", nil)
+	require.NoError(t, err)
+	require.Equal(t, "body", result)
+
+	require.NotNil(t, preMeta)
+	require.NotNil(t, postMeta)
+	require.True(t, postMeta.HasTag("internal"))
+}
+
+func TestEngine_FrontMatter_EscapeMode(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"raw.tpl":     "---\nescape: none\n---\n{{ value }}",
+		"escaped.tpl": "---\nescape: html\n---\n{% autoescape off %}{% endautoescape %}{{ value }}",
+	})
+
+	renderer, err := template.NewRenderer(template.WithBaseDir(dir))
+	require.NoError(t, err)
+
+	data := map[string]any{"value": "<b>&</b>"}
+
+	result, err := renderer.RenderTemplate("raw", data)
+	require.NoError(t, err)
+	require.Equal(t, "<b>&</b>", result)
+
+	result, err = renderer.RenderTemplate("escaped", data)
+	require.NoError(t, err)
+	require.Equal(t, "&lt;b&gt;&amp;&lt;/b&gt;", result)
+}
+
+func TestEngine_FrontMatter_RenderString(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithBaseDir(t.TempDir()))
+	require.NoError(t, err)
+
+	var seen *template.TemplateMetadata
+	renderer.RegisterPostHook(func(ctx *template.HookContext) (string, error) {
+		seen, _ = ctx.Metadata[template.MetadataFrontMatterKey].(*template.TemplateMetadata)
+		return ctx.Output, nil
+	})
+
+	result, err := renderer.RenderString("+++\n[defaults]\nname = \"World\"\n+++\nHello {{ name }}", nil)
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", result)
+	require.NotNil(t, seen)
+	require.Equal(t, template.FrontMatterTOML, seen.Format)
+}
+
+func TestEngine_WithFrontMatterDisabled(t *testing.T) {
+	content := "---\ndescription: kept\n---\nbody"
+	dir := writeTemplates(t, map[string]string{"verbatim.tpl": content})
+
+	renderer, err := template.NewRenderer(template.WithBaseDir(dir), template.WithFrontMatter(false))
+	require.NoError(t, err)
+
+	result, err := renderer.RenderTemplate("verbatim", nil)
+	require.NoError(t, err)
+	require.Equal(t, content, result)
+
+	meta, err := renderer.Metadata("verbatim")
+	require.NoError(t, err)
+	require.Nil(t, meta)
+}
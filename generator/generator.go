@@ -0,0 +1,318 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goliatone/go-template"
+)
+
+// Prompter asks the user for an input value missing from the render data.
+type Prompter interface {
+	Prompt(input Input) (any, error)
+}
+
+// PrompterFunc adapts a function into a Prompter.
+type PrompterFunc func(input Input) (any, error)
+
+// Prompt implements Prompter.
+func (f PrompterFunc) Prompt(input Input) (any, error) {
+	return f(input)
+}
+
+// Generator renders the files declared by a Manifest with an Engine.
+type Generator struct {
+	engine    *template.Engine
+	manifest  *Manifest
+	preHooks  map[string]template.PreHook
+	postHooks map[string]template.PostHook
+	prompter  Prompter
+}
+
+// Option configures a Generator.
+type Option func(*Generator)
+
+// WithHook registers a post hook that manifests can reference by name.
+func WithHook(name string, hook template.PostHook) Option {
+	return func(g *Generator) {
+		g.postHooks[name] = hook
+	}
+}
+
+// WithPreHook registers a pre hook that manifests can reference by name.
+func WithPreHook(name string, hook template.PreHook) Option {
+	return func(g *Generator) {
+		g.preHooks[name] = hook
+	}
+}
+
+// WithPrompter asks for required inputs missing from the render data.
+func WithPrompter(p Prompter) Option {
+	return func(g *Generator) {
+		g.prompter = p
+	}
+}
+
+// New creates a generator for the manifest. Template paths are resolved in the
+// engine file system, relative to manifest.Dir.
+func New(engine *template.Engine, manifest *Manifest, opts ...Option) *Generator {
+	g := &Generator{
+		engine:    engine,
+		manifest:  manifest,
+		preHooks:  make(map[string]template.PreHook),
+		postHooks: make(map[string]template.PostHook),
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Load reads the manifest at manifestPath from the engine file system and
+// returns a generator for it.
+func Load(engine *template.Engine, manifestPath string, opts ...Option) (*Generator, error) {
+	fsys, err := engine.SourceFS()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := LoadManifest(fsys, manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(engine, manifest, opts...), nil
+}
+
+// Manifest returns the manifest driving the generator.
+func (g *Generator) Manifest() *Manifest {
+	return g.manifest
+}
+
+// Inputs resolves the manifest inputs against data: defaults fill missing
+// values, the prompter is asked for missing required values, and every value
+// is coerced to its declared type and checked against its enum. All problems
+// are reported together. Keys not declared as inputs are passed through.
+func (g *Generator) Inputs(data map[string]any) (map[string]any, error) {
+	values := make(map[string]any, len(data)+len(g.manifest.Inputs))
+	maps.Copy(values, data)
+
+	var errs []error
+	for _, in := range g.manifest.Inputs {
+		value, ok := values[in.Name]
+		if !ok && in.Default != nil {
+			value, ok = in.Default, true
+		}
+		if !ok && in.Required && g.prompter != nil {
+			prompted, err := g.prompter.Prompt(in)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("input %q: %w", in.Name, err))
+				continue
+			}
+			value, ok = prompted, prompted != nil
+		}
+		if !ok {
+			if in.Required {
+				errs = append(errs, fmt.Errorf("input %q is required", in.Name))
+			}
+			continue
+		}
+
+		coerced, err := in.Coerce(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("input %q: %w", in.Name, err))
+			continue
+		}
+
+		if len(in.Enum) > 0 && !inEnum(in, coerced) {
+			errs = append(errs, fmt.Errorf("input %q: %v is not one of %v", in.Name, coerced, in.Enum))
+			continue
+		}
+
+		values[in.Name] = coerced
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return values, nil
+}
+
+func inEnum(in Input, value any) bool {
+	for _, candidate := range in.Enum {
+		c, err := in.Coerce(candidate)
+		if err == nil && reflect.DeepEqual(c, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate resolves inputs and renders every manifest file whose condition
+// holds. Nothing is written to disk; see template.WriteFiles.
+func (g *Generator) Generate(data map[string]any) ([]template.GeneratedFile, error) {
+	values, err := g.Inputs(data)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys, err := g.engine.SourceFS()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []template.GeneratedFile
+	seen := make(map[string]string)
+
+	for _, entry := range g.manifest.Files {
+		file, ok, err := g.generateFile(fsys, entry, values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Template, err)
+		}
+		if !ok {
+			continue
+		}
+
+		if prev, exists := seen[file.Path]; exists {
+			return nil, fmt.Errorf("output path %s produced by both %s and %s", file.Path, prev, entry.Template)
+		}
+		seen[file.Path] = entry.Template
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func (g *Generator) generateFile(fsys fs.FS, entry FileEntry, values map[string]any) (template.GeneratedFile, bool, error) {
+	if entry.Condition != "" {
+		ok, err := g.evaluate(entry.Condition, values)
+		if err != nil || !ok {
+			return template.GeneratedFile{}, false, err
+		}
+	}
+
+	source := path.Join(g.manifest.Dir, entry.Template)
+
+	// like GenerateTree, templates may declare their output path and write
+	// policy in front matter; manifest entries take precedence
+	var meta *template.TemplateMetadata
+	if !entry.Copy && (entry.Output == "" || entry.Policy == "") {
+		var err error
+		if meta, err = g.engine.Metadata(source); err != nil {
+			return template.GeneratedFile{}, false, err
+		}
+	}
+
+	output := entry.Output
+	if output == "" && meta != nil {
+		output = meta.Output
+	}
+	if output == "" {
+		output = entry.Template
+		if !entry.Copy {
+			output = strings.TrimSuffix(output, g.engine.Extension())
+		}
+	}
+
+	output, err := g.engine.Expand(output, values)
+	if err != nil {
+		return template.GeneratedFile{}, false, fmt.Errorf("failed to render output path: %w", err)
+	}
+
+	var file template.GeneratedFile
+	if entry.Copy {
+		content, err := fs.ReadFile(fsys, source)
+		if err != nil {
+			return template.GeneratedFile{}, false, err
+		}
+		file = template.GeneratedFile{Path: output, Source: source, Content: content}
+	} else {
+		pre, post, err := g.resolveHooks(entry)
+		if err != nil {
+			return template.GeneratedFile{}, false, err
+		}
+
+		file, err = g.engine.RenderFile(source, values,
+			template.WithOutputPath(output),
+			template.WithFilePreHooks(pre...),
+			template.WithFilePostHooks(post...),
+		)
+		if err != nil {
+			return template.GeneratedFile{}, false, err
+		}
+	}
+
+	if file.Path, err = template.CleanOutputPath(file.Path); err != nil {
+		return template.GeneratedFile{}, false, fmt.Errorf("invalid output path for %s: %w", entry.Template, err)
+	}
+
+	if file.Mode, err = g.fileMode(fsys, source, entry); err != nil {
+		return template.GeneratedFile{}, false, err
+	}
+
+	if entry.Policy != "" {
+		if file.Policy, err = template.ParseWritePolicy(entry.Policy); err != nil {
+			return template.GeneratedFile{}, false, err
+		}
+	} else if meta != nil {
+		file.Policy = meta.WritePolicy
+	}
+
+	return file, true, nil
+}
+
+func (g *Generator) evaluate(condition string, values map[string]any) (bool, error) {
+	out, err := g.engine.Expand("{% if "+condition+" %}true{% endif %}", values)
+	if err != nil {
+		return false, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+	return out == "true", nil
+}
+
+func (g *Generator) resolveHooks(entry FileEntry) ([]template.PreHook, []template.PostHook, error) {
+	var pre []template.PreHook
+	var post []template.PostHook
+
+	names := append(append([]string{}, g.manifest.Hooks...), entry.Hooks...)
+	for _, name := range names {
+		hook, isPre := g.preHooks[name]
+		postHook, isPost := g.postHooks[name]
+		if !isPre && !isPost {
+			return nil, nil, fmt.Errorf("unknown hook %q", name)
+		}
+		if isPre {
+			pre = append(pre, hook)
+		}
+		if isPost {
+			post = append(post, postHook)
+		}
+	}
+
+	return pre, post, nil
+}
+
+func (g *Generator) fileMode(fsys fs.FS, source string, entry FileEntry) (fs.FileMode, error) {
+	if entry.Mode != "" {
+		mode, err := strconv.ParseUint(entry.Mode, 8, 32)
+		if err != nil {
+			return 0, err
+		}
+		return fs.FileMode(mode), nil
+	}
+
+	info, err := fs.Stat(fsys, source)
+	if err != nil {
+		return 0, err
+	}
+	return info.Mode().Perm(), nil
+}
@@ -0,0 +1,237 @@
+package generator_test
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/generator"
+	"github.com/stretchr/testify/require"
+)
+
+const serviceManifest = `
+name: service
+description: Go service skeleton
+inputs:
+  - name: package_name
+    type: string
+    required: true
+    prompt: Go package name
+  - name: port
+    type: int
+    default: 8080
+  - name: with_docker
+    type: bool
+    default: false
+  - name: env
+    enum: [dev, prod]
+    default: dev
+hooks: [stamp]
+files:
+  - template: model.go.tpl
+    output: "{{ package_name }}/model.go"
+    hooks: [upper]
+  - template: Dockerfile.tpl
+    condition: with_docker
+    policy: create-only
+  - template: config.yaml.tpl
+    condition: env == "prod"
+  - template: scripts/run.sh
+    copy: true
+    mode: "0755"
+`
+
+func packFS() fstest.MapFS {
+	return fstest.MapFS{
+		"packs/service/generator.yaml":  {Data: []byte(serviceManifest)},
+		"packs/service/model.go.tpl":    {Data: []byte("package {{ package_name }} // port {{ port|integer }}\n")},
+		"packs/service/Dockerfile.tpl":  {Data: []byte("EXPOSE {{ port|integer }}\n")},
+		"packs/service/config.yaml.tpl": {Data: []byte("env: {{ env }}\n")},
+		"packs/service/scripts/run.sh":  {Data: []byte("#!/bin/sh\necho {{ raw }}\n"), Mode: 0o644},
+	}
+}
+
+func newGenerator(t *testing.T, opts ...generator.Option) *generator.Generator {
+	t.Helper()
+
+	renderer, err := template.NewRenderer(template.WithFS(packFS()))
+	require.NoError(t, err)
+
+	opts = append([]generator.Option{
+		generator.WithHook("stamp", func(ctx *template.HookContext) (string, error) {
+			return "# " + ctx.OutputPath + "\n" + ctx.Output, nil
+		}),
+		generator.WithHook("upper", func(ctx *template.HookContext) (string, error) {
+			return strings.ToUpper(ctx.Output), nil
+		}),
+	}, opts...)
+
+	g, err := generator.Load(renderer, "packs/service/generator.yaml", opts...)
+	require.NoError(t, err)
+	return g
+}
+
+func filesByPath(files []template.GeneratedFile) map[string]template.GeneratedFile {
+	out := make(map[string]template.GeneratedFile, len(files))
+	for _, f := range files {
+		out[f.Path] = f
+	}
+	return out
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	g := newGenerator(t)
+	require.Equal(t, "service", g.Manifest().Name)
+
+	files, err := g.Generate(map[string]any{"package_name": "api"})
+	require.NoError(t, err)
+
+	byPath := filesByPath(files)
+	require.Len(t, byPath, 2)
+	require.Equal(t, "# API/MODEL.GO\nPACKAGE API // PORT 8080\n", string(byPath["api/model.go"].Content))
+
+	script := byPath["scripts/run.sh"]
+	require.False(t, script.IsTemplate)
+	require.Equal(t, "#!/bin/sh\necho {{ raw }}\n", string(script.Content))
+	require.Equal(t, fs.FileMode(0o755), script.Mode)
+}
+
+func TestGenerator_Conditions(t *testing.T) {
+	g := newGenerator(t)
+
+	files, err := g.Generate(map[string]any{
+		"package_name": "api",
+		"with_docker":  "true",
+		"env":          "prod",
+		"port":         "9000",
+	})
+	require.NoError(t, err)
+
+	byPath := filesByPath(files)
+	require.Len(t, byPath, 4)
+	require.Equal(t, "# Dockerfile\nEXPOSE 9000\n", string(byPath["Dockerfile"].Content))
+	require.Equal(t, template.WriteCreateOnly, byPath["Dockerfile"].Policy)
+	require.Equal(t, "# config.yaml\nenv: prod\n", string(byPath["config.yaml"].Content))
+}
+
+func TestGenerator_FrontMatterOutputAndPolicy(t *testing.T) {
+	fsys := fstest.MapFS{
+		"packs/conf/generator.yaml": {Data: []byte("name: conf\nfiles:\n  - template: app.tpl\n  - template: db.tpl\n    output: db/{{ name }}.yaml\n    policy: always\n")},
+		"packs/conf/app.tpl":        {Data: []byte("---\noutput: \"config/{{ name }}.yaml\"\nwrite_policy: create-only\n---\napp: {{ name }}\n")},
+		"packs/conf/db.tpl":         {Data: []byte("---\noutput: ignored.yaml\nwrite_policy: create-only\n---\ndb: {{ name }}\n")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	g, err := generator.Load(renderer, "packs/conf/generator.yaml")
+	require.NoError(t, err)
+
+	files, err := g.Generate(map[string]any{"name": "demo"})
+	require.NoError(t, err)
+
+	byPath := filesByPath(files)
+	require.Len(t, byPath, 2)
+	require.Equal(t, "app: demo\n", string(byPath["config/demo.yaml"].Content))
+	require.Equal(t, template.WriteCreateOnly, byPath["config/demo.yaml"].Policy)
+	require.Equal(t, "db: demo\n", string(byPath["db/demo.yaml"].Content))
+	require.Equal(t, template.WriteAlways, byPath["db/demo.yaml"].Policy)
+}
+
+func TestGenerator_InvalidOutputPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		message string
+	}{
+		{name: "empty", output: `"{{ missing }}"`, message: "empty path"},
+		{name: "dot", output: `"."`, message: "empty path"},
+		{name: "dot segments", output: "sub/..", message: "empty path"},
+		{name: "escaping", output: "../app.yaml", message: "escapes the output directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"packs/conf/generator.yaml": {Data: []byte("name: conf\nfiles:\n  - template: app.tpl\n    output: " + tt.output + "\n")},
+				"packs/conf/app.tpl":        {Data: []byte("app\n")},
+			}
+			renderer, err := template.NewRenderer(template.WithFS(fsys))
+			require.NoError(t, err)
+
+			g, err := generator.Load(renderer, "packs/conf/generator.yaml")
+			require.NoError(t, err)
+
+			_, err = g.Generate(map[string]any{})
+			require.ErrorContains(t, err, "invalid output path for app.tpl")
+			require.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestGenerator_InputsErrors(t *testing.T) {
+	g := newGenerator(t)
+
+	_, err := g.Inputs(map[string]any{"port": "abc", "env": "qa"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `input "package_name" is required`)
+	require.Contains(t, err.Error(), `input "port"`)
+	require.Contains(t, err.Error(), `input "env"`)
+}
+
+func TestGenerator_Prompter(t *testing.T) {
+	var asked []string
+	g := newGenerator(t, generator.WithPrompter(generator.PrompterFunc(func(in generator.Input) (any, error) {
+		asked = append(asked, in.Prompt)
+		return "prompted", nil
+	})))
+
+	values, err := g.Inputs(map[string]any{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Go package name"}, asked)
+	require.Equal(t, "prompted", values["package_name"])
+	require.Equal(t, 8080, values["port"])
+
+	failing := newGenerator(t, generator.WithPrompter(generator.PrompterFunc(func(in generator.Input) (any, error) {
+		return nil, errors.New("no tty")
+	})))
+	_, err = failing.Inputs(nil)
+	require.ErrorContains(t, err, "no tty")
+}
+
+func TestGenerator_UnknownHook(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithFS(packFS()))
+	require.NoError(t, err)
+
+	g, err := generator.Load(renderer, "packs/service/generator.yaml")
+	require.NoError(t, err)
+
+	_, err = g.Generate(map[string]any{"package_name": "api"})
+	require.ErrorContains(t, err, `unknown hook "stamp"`)
+}
+
+func TestParseManifest_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		message  string
+	}{
+		{name: "no files", manifest: "name: x\n", message: "no files declared"},
+		{name: "unnamed input", manifest: "inputs: [{type: string}]\nfiles: [{template: a}]\n", message: "has no name"},
+		{name: "duplicate input", manifest: "inputs: [{name: a}, {name: a}]\nfiles: [{template: a}]\n", message: "duplicate input"},
+		{name: "unknown type", manifest: "inputs: [{name: a, type: date}]\nfiles: [{template: a}]\n", message: "unknown type"},
+		{name: "bad default", manifest: "inputs: [{name: a, type: int, default: abc}]\nfiles: [{template: a}]\n", message: "default of input"},
+		{name: "missing template", manifest: "files: [{output: a}]\n", message: "has no template"},
+		{name: "bad mode", manifest: "files: [{template: a, mode: \"999\"}]\n", message: "invalid mode"},
+		{name: "bad policy", manifest: "files: [{template: a, policy: sometimes}]\n", message: "unknown write policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := generator.ParseManifest([]byte(tt.manifest))
+			require.ErrorContains(t, err, tt.message)
+		})
+	}
+}
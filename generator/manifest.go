@@ -0,0 +1,249 @@
+// Package generator drives go-template from a declarative manifest describing
+// a template pack: its inputs, the files it generates and the hooks each file
+// runs.
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"strconv"
+
+	"github.com/goliatone/go-template"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultManifestName is the manifest file looked up in a template pack.
+const DefaultManifestName = "generator.yaml"
+
+// InputType is the declared type of a manifest input.
+type InputType string
+
+const (
+	InputString  InputType = "string"
+	InputInt     InputType = "int"
+	InputNumber  InputType = "number"
+	InputBool    InputType = "bool"
+	InputList    InputType = "list"
+	InputMap     InputType = "map"
+	InputDefault           = InputString
+)
+
+// Manifest describes a template pack.
+//
+//	name: service
+//	inputs:
+//	  - name: package_name
+//	    type: string
+//	    required: true
+//	    prompt: Go package name
+//	  - name: with_docker
+//	    type: bool
+//	    default: false
+//	hooks: [generated_warning]
+//	files:
+//	  - template: model.go.tpl
+//	    output: "{{ package_name }}/model.go"
+//	  - template: Dockerfile.tpl
+//	    condition: with_docker
+//	    policy: create-only
+//	  - template: assets/logo.svg
+//	    copy: true
+type Manifest struct {
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	Inputs      []Input     `yaml:"inputs"`
+	Files       []FileEntry `yaml:"files"`
+	// Hooks are applied to every templated file, before per file hooks.
+	Hooks []string `yaml:"hooks"`
+
+	// Dir is the manifest directory in the engine file system. Template paths
+	// are resolved relative to it.
+	Dir string `yaml:"-"`
+}
+
+// Input declares a value the pack expects in its render data.
+type Input struct {
+	Name        string    `yaml:"name"`
+	Type        InputType `yaml:"type"`
+	Description string    `yaml:"description"`
+	Prompt      string    `yaml:"prompt"`
+	Required    bool      `yaml:"required"`
+	Default     any       `yaml:"default"`
+	Enum        []any     `yaml:"enum"`
+}
+
+// FileEntry declares one generated file.
+type FileEntry struct {
+	// Template is the source path relative to the manifest directory.
+	Template string `yaml:"template"`
+	// Output is a template for the output path. It defaults to the template
+	// front matter output, then to Template with the engine template
+	// extension stripped.
+	Output string `yaml:"output"`
+	// Condition is a pongo2 expression; the file is skipped when it is falsy.
+	Condition string `yaml:"condition"`
+	// Hooks lists registered hook names run for this file only.
+	Hooks []string `yaml:"hooks"`
+	// Copy writes the source verbatim instead of rendering it.
+	Copy bool `yaml:"copy"`
+	// Mode is an optional octal permission string such as "0755".
+	Mode string `yaml:"mode"`
+	// Policy is the write policy of the file, see template.WritePolicy. It
+	// defaults to the template front matter write_policy.
+	Policy string `yaml:"policy"`
+}
+
+// ParseManifest decodes a YAML manifest and validates its structure.
+func ParseManifest(content []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := yaml.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// LoadManifest reads and parses the manifest at p in fsys.
+func LoadManifest(fsys fs.FS, p string) (*Manifest, error) {
+	content, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read manifest: %w", err)
+	}
+
+	m, err := ParseManifest(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	m.Dir = path.Dir(p)
+
+	return m, nil
+}
+
+// Validate checks the manifest for missing or inconsistent fields.
+func (m *Manifest) Validate() error {
+	names := make(map[string]bool, len(m.Inputs))
+	for i := range m.Inputs {
+		in := &m.Inputs[i]
+		if in.Name == "" {
+			return fmt.Errorf("invalid manifest: input %d has no name", i)
+		}
+		if names[in.Name] {
+			return fmt.Errorf("invalid manifest: duplicate input %q", in.Name)
+		}
+		names[in.Name] = true
+
+		if in.Type == "" {
+			in.Type = InputDefault
+		}
+		switch in.Type {
+		case InputString, InputInt, InputNumber, InputBool, InputList, InputMap:
+		default:
+			return fmt.Errorf("invalid manifest: input %q has unknown type %q", in.Name, in.Type)
+		}
+
+		if in.Default != nil {
+			value, err := in.Coerce(in.Default)
+			if err != nil {
+				return fmt.Errorf("invalid manifest: default of input %q: %w", in.Name, err)
+			}
+			in.Default = value
+		}
+	}
+
+	if len(m.Files) == 0 {
+		return fmt.Errorf("invalid manifest: no files declared")
+	}
+
+	for i, f := range m.Files {
+		if _, err := template.ParseWritePolicy(f.Policy); err != nil {
+			return fmt.Errorf("invalid manifest: file %s: %w", f.Template, err)
+		}
+		if f.Template == "" {
+			return fmt.Errorf("invalid manifest: file %d has no template", i)
+		}
+		if f.Mode != "" {
+			if _, err := strconv.ParseUint(f.Mode, 8, 32); err != nil {
+				return fmt.Errorf("invalid manifest: file %s has invalid mode %q", f.Template, f.Mode)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Coerce converts value into the input type, accepting strings for scalar
+// types so values coming from flags or prompts can be used directly.
+func (in Input) Coerce(value any) (any, error) {
+	switch in.Type {
+	case InputString, "":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case int, int64, float64, bool:
+			return fmt.Sprint(v), nil
+		}
+	case InputInt:
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case int64:
+			return int(v), nil
+		case float64:
+			if v == float64(int(v)) {
+				return int(v), nil
+			}
+		case string:
+			if i, err := strconv.Atoi(v); err == nil {
+				return i, nil
+			}
+		}
+	case InputNumber:
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, nil
+			}
+		}
+	case InputBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		}
+	case InputList:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			out := make([]any, rv.Len())
+			for i := range out {
+				out[i] = rv.Index(i).Interface()
+			}
+			return out, nil
+		}
+	case InputMap:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+			out := make(map[string]any, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				out[iter.Key().String()] = iter.Value().Interface()
+			}
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %T", in.Type, value)
+}
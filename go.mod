@@ -3,12 +3,11 @@ module github.com/goliatone/go-template
 go 1.23.4
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/flosch/pongo2/v6 v6.0.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.11.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
-)
+require github.com/davecgh/go-spew v1.1.1 // indirect
@@ -1,7 +1,10 @@
 package template
 
 import (
+	"errors"
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -13,25 +16,168 @@ type HookContext struct {
 	Output       string
 	Metadata     map[string]any
 	IsPreHook    bool
+	// OutputPath is the destination of the rendered file when rendering as part
+	// of a file tree (see GenerateTree), relative to the output root. It is
+	// empty for plain RenderTemplate and RenderString calls.
+	OutputPath string
+	// Clock is the engine clock. Hooks stamping times should prefer it over
+	// time.Now so output stays reproducible.
+	Clock Clock
 }
 
 type PreHook func(ctx *HookContext) error // modify Data or Metadata
 type PostHook func(ctx *HookContext) (string, error)
 
+// HookError reports a hook failure together with the template it ran for and,
+// when the hook checks the output, the position of the problem in it.
+type HookError struct {
+	Hook         string
+	TemplateName string
+	// Line and Column are 1-based positions in ctx.Output, or zero.
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *HookError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "hook %s", e.Hook)
+	if e.TemplateName != "" {
+		fmt.Fprintf(&b, " (template %s)", e.TemplateName)
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, " at line %d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ", column %d", e.Column)
+		}
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
 // HookCondition allows callers to decide whether a hook should run for a given context.
 type HookCondition func(ctx *HookContext) bool
 
+// HookKind tells pre hooks from post hooks in HookInfo.
+type HookKind string
+
+const (
+	HookKindPre         HookKind = "pre"
+	HookKindPost        HookKind = "post"
+	HookKindLoad        HookKind = "load"
+	HookKindError       HookKind = "error"
+	HookKindWrite       HookKind = "write"
+	HookKindAfterRender HookKind = "after-render"
+)
+
+// hookKinds lists hook kinds in the order they run during a render.
+var hookKinds = []HookKind{HookKindPre, HookKindLoad, HookKindPost, HookKindError, HookKindWrite, HookKindAfterRender}
+
+var (
+	// ErrHookExists is returned when registering a hook under a name in use.
+	ErrHookExists = errors.New("hook already registered")
+	// ErrHookNotFound is returned when no hook is registered under a name.
+	ErrHookNotFound = errors.New("hook not found")
+)
+
+// HookInfo describes a registered hook, see HookManager.List.
+type HookInfo struct {
+	// Name is empty for hooks registered without one.
+	Name     string
+	Kind     HookKind
+	Priority int
+	Enabled  bool
+	// Scope restricts the renders the hook runs for.
+	Scope HookScope
+}
+
+// HookOption configures a named hook registration.
+type HookOption func(*hookEntry)
+
+// WithHookPriority sets the hook priority, 0 by default. Lower priorities run
+// first; hooks with the same priority run in registration order.
+func WithHookPriority(priority int) HookOption {
+	return func(e *hookEntry) {
+		e.priority = priority
+	}
+}
+
+// WithHookName names a lifecycle hook registered with OnLoad, OnError, OnWrite
+// or AfterRender, so it can be removed, toggled and listed like named pre and
+// post hooks.
+func WithHookName(name string) HookOption {
+	return func(e *hookEntry) {
+		e.name = name
+	}
+}
+
+// WithHookEnabled registers the hook enabled or disabled, see
+// HookManager.SetEnabled.
+func WithHookEnabled(enabled bool) HookOption {
+	return func(e *hookEntry) {
+		e.enabled = enabled
+	}
+}
+
+type hookEntry struct {
+	name     string
+	kind     HookKind
+	priority int
+	seq      int
+	enabled  bool
+	scope    HookScope
+	pre      PreHook
+	post     PostHook
+	load     LoadHook
+	onError  ErrorHook
+	write    WriteHook
+	after    AfterRenderHook
+}
+
+func (e *hookEntry) info() HookInfo {
+	return HookInfo{Name: e.name, Kind: e.kind, Priority: e.priority, Enabled: e.enabled, Scope: e.scope}
+}
+
+// preHook returns the hook guarded by its scope.
+func (e *hookEntry) preHook() PreHook {
+	if e.scope.IsZero() {
+		return e.pre
+	}
+	hook, scope := e.pre, e.scope
+	return func(ctx *HookContext) error {
+		if !scope.Matches(ctx) {
+			return nil
+		}
+		return hook(ctx)
+	}
+}
+
+// postHook returns the hook guarded by its scope.
+func (e *hookEntry) postHook() PostHook {
+	if e.scope.IsZero() {
+		return e.post
+	}
+	hook, scope := e.post, e.scope
+	return func(ctx *HookContext) (string, error) {
+		if !scope.Matches(ctx) {
+			return ctx.Output, nil
+		}
+		return hook(ctx)
+	}
+}
+
 type HookManager struct {
-	mu        sync.RWMutex
-	preHooks  map[int][]PreHook
-	postHooks map[int][]PostHook
+	mu      sync.RWMutex
+	entries []*hookEntry
+	seq     int
 }
 
 func NewHooksManager() *HookManager {
-	return &HookManager{
-		preHooks:  make(map[int][]PreHook, 0),
-		postHooks: make(map[int][]PostHook, 0),
-	}
+	return &HookManager{}
 }
 
 // AddPreHook registers a pre generation hook
@@ -39,52 +185,176 @@ func (e *HookManager) AddPreHook(hook PreHook, priority ...int) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	p := 0
-	if len(priority) > 0 {
-		p = priority[0]
+	e.addLocked(&hookEntry{kind: HookKindPre, priority: firstPriority(priority), enabled: true, pre: hook})
+}
+
+// AddPostHook registers a post generation hook
+func (e *HookManager) AddPostHook(hook PostHook, priority ...int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.addLocked(&hookEntry{kind: HookKindPost, priority: firstPriority(priority), enabled: true, post: hook})
+}
+
+// AddNamedPreHook registers a pre generation hook under name so it can later
+// be removed, replaced, toggled and listed. Names are unique across pre and
+// post hooks. Scope options such as WithHookTemplates restrict the renders the
+// hook runs for.
+func (e *HookManager) AddNamedPreHook(name string, hook PreHook, opts ...HookOption) error {
+	return e.addNamed(&hookEntry{name: name, kind: HookKindPre, enabled: true, pre: hook}, opts)
+}
+
+// AddNamedPostHook registers a post generation hook under name, see
+// AddNamedPreHook.
+func (e *HookManager) AddNamedPostHook(name string, hook PostHook, opts ...HookOption) error {
+	return e.addNamed(&hookEntry{name: name, kind: HookKindPost, enabled: true, post: hook}, opts)
+}
+
+func (e *HookManager) addNamed(entry *hookEntry, opts []HookOption) error {
+	if entry.name == "" {
+		return fmt.Errorf("hook name is required")
 	}
+	return e.addEntry(entry, opts)
+}
+
+func (e *HookManager) addEntry(entry *hookEntry, opts []HookOption) error {
+	for _, opt := range opts {
+		opt(entry)
+	}
+
+	if err := entry.scope.validate(); err != nil {
+		return fmt.Errorf("hook %s: %w", entry.name, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	hooks, ok := e.preHooks[p]
-	if !ok {
-		hooks = make([]PreHook, 0)
+	if entry.name != "" && e.findLocked(entry.name) != nil {
+		return fmt.Errorf("%w: %s", ErrHookExists, entry.name)
 	}
 
-	e.preHooks[p] = append(hooks, hook)
+	e.addLocked(entry)
+	return nil
+}
+
+func (e *HookManager) addLocked(entry *hookEntry) {
+	e.seq++
+	entry.seq = e.seq
+	e.entries = append(e.entries, entry)
 
+	sort.SliceStable(e.entries, func(i, j int) bool {
+		if e.entries[i].priority != e.entries[j].priority {
+			return e.entries[i].priority < e.entries[j].priority
+		}
+		return e.entries[i].seq < e.entries[j].seq
+	})
 }
 
-// AddPostHook registers a post generation hook
-func (e *HookManager) AddPostHook(hook PostHook, priority ...int) {
+func (e *HookManager) findLocked(name string) *hookEntry {
+	if name == "" {
+		return nil
+	}
+	for _, entry := range e.entries {
+		if entry.name == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Remove unregisters the hook registered under name. It reports whether such
+// a hook existed.
+func (e *HookManager) Remove(name string) bool {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	p := 0
-	if len(priority) > 0 {
-		p = priority[0]
+	for i, entry := range e.entries {
+		if name != "" && entry.name == name {
+			e.entries = append(e.entries[:i], e.entries[i+1:]...)
+			return true
+		}
 	}
+	return false
+}
+
+// ReplacePreHook swaps the pre hook registered under name, keeping its
+// priority, position and enabled state.
+func (e *HookManager) ReplacePreHook(name string, hook PreHook) error {
+	return e.replace(name, HookKindPre, func(entry *hookEntry) { entry.pre = hook })
+}
 
-	hooks, ok := e.postHooks[p]
-	if !ok {
-		hooks = make([]PostHook, 0)
+// ReplacePostHook swaps the post hook registered under name, keeping its
+// priority, position and enabled state.
+func (e *HookManager) ReplacePostHook(name string, hook PostHook) error {
+	return e.replace(name, HookKindPost, func(entry *hookEntry) { entry.post = hook })
+}
+
+func (e *HookManager) replace(name string, kind HookKind, set func(*hookEntry)) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry := e.findLocked(name)
+	if entry == nil {
+		return fmt.Errorf("%w: %s", ErrHookNotFound, name)
+	}
+	if entry.kind != kind {
+		return fmt.Errorf("hook %s is a %s hook, not a %s hook", name, entry.kind, kind)
 	}
 
-	e.postHooks[p] = append(hooks, hook)
+	set(entry)
+	return nil
 }
 
-func (e *HookManager) PreHooks() []PreHook {
+// SetEnabled enables or disables the hook registered under name. Disabled
+// hooks stay registered but are skipped by PreHooks and PostHooks.
+func (e *HookManager) SetEnabled(name string, enabled bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry := e.findLocked(name)
+	if entry == nil {
+		return fmt.Errorf("%w: %s", ErrHookNotFound, name)
+	}
+
+	entry.enabled = enabled
+	return nil
+}
+
+// Enabled reports whether a hook is registered under name and enabled.
+func (e *HookManager) Enabled(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	entry := e.findLocked(name)
+	return entry != nil && entry.enabled
+}
+
+// List describes the registered hooks, including disabled ones, in execution
+// order: pre hooks first, then load, post, error, write and after render hooks.
+func (e *HookManager) List() []HookInfo {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	keys := []int{}
-	for k := range e.preHooks {
-		keys = append(keys, k)
+	out := make([]HookInfo, 0, len(e.entries))
+	for _, kind := range hookKinds {
+		for _, entry := range e.entries {
+			if entry.kind == kind {
+				out = append(out, entry.info())
+			}
+		}
 	}
+	return out
+}
 
-	sort.Ints(keys)
+func (e *HookManager) PreHooks() []PreHook {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 
 	out := make([]PreHook, 0)
-	for _, priority := range keys {
-		out = append(out, e.preHooks[priority]...)
+	for _, entry := range e.entries {
+		if entry.kind == HookKindPre && entry.enabled {
+			out = append(out, entry.preHook())
+		}
 	}
 
 	return out
@@ -94,21 +364,109 @@ func (e *HookManager) PostHooks() []PostHook {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	keys := []int{}
-	for k := range e.postHooks {
-		keys = append(keys, k)
+	out := make([]PostHook, 0)
+	for _, entry := range e.entries {
+		if entry.kind == HookKindPost && entry.enabled {
+			out = append(out, entry.postHook())
+		}
 	}
 
-	sort.Ints(keys)
+	return out
+}
+
+// enabled returns copies of the enabled entries of kind in execution order,
+// taken under the lock so a concurrent ReplacePreHook or ReplacePostHook does
+// not race with the render using them.
+func (e *HookManager) enabled(kind HookKind) []hookEntry {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 
-	out := make([]PostHook, 0)
-	for _, priority := range keys {
-		out = append(out, e.postHooks[priority]...)
+	var out []hookEntry
+	for _, entry := range e.entries {
+		if entry.kind == kind && entry.enabled {
+			out = append(out, *entry)
+		}
 	}
+	return out
+}
 
+// labeledPreHook is a pre hook with the name panics are reported under and
+// the scope the engine checks before running, and tracing, it.
+type labeledPreHook struct {
+	label string
+	hook  PreHook
+	scope HookScope
+}
+
+func (h labeledPreHook) applies(ctx *HookContext) bool {
+	return h.scope.IsZero() || h.scope.Matches(ctx)
+}
+
+func (h labeledPreHook) run(ctx *HookContext) error {
+	return guard(h.label, ctx.TemplateName, func() error {
+		return h.hook(ctx)
+	})
+}
+
+// labeledPostHook is a post hook with the name panics are reported under and
+// the scope the engine checks before running, and tracing, it.
+type labeledPostHook struct {
+	label string
+	hook  PostHook
+	scope HookScope
+}
+
+func (h labeledPostHook) applies(ctx *HookContext) bool {
+	return h.scope.IsZero() || h.scope.Matches(ctx)
+}
+
+func (h labeledPostHook) run(ctx *HookContext) (output string, err error) {
+	err = guard(h.label, ctx.TemplateName, func() error {
+		output, err = h.hook(ctx)
+		return err
+	})
+	return output, err
+}
+
+// preHooks returns the registered pre hooks followed by extra.
+func (r *Engine) preHooks(extra []PreHook) []labeledPreHook {
+	entries := r.hooks.enabled(HookKindPre)
+	out := make([]labeledPreHook, 0, len(entries)+len(extra))
+	for _, entry := range entries {
+		out = append(out, labeledPreHook{label: entry.name, hook: entry.pre, scope: entry.scope})
+	}
+	for _, hook := range extra {
+		out = append(out, labeledPreHook{hook: hook})
+	}
+	for i := range out {
+		out[i].label = hookLabel(out[i].label, HookKindPre, i)
+	}
+	return out
+}
+
+// postHooks returns the registered post hooks followed by extra.
+func (r *Engine) postHooks(extra []PostHook) []labeledPostHook {
+	entries := r.hooks.enabled(HookKindPost)
+	out := make([]labeledPostHook, 0, len(entries)+len(extra))
+	for _, entry := range entries {
+		out = append(out, labeledPostHook{label: entry.name, hook: entry.post, scope: entry.scope})
+	}
+	for _, hook := range extra {
+		out = append(out, labeledPostHook{hook: hook})
+	}
+	for i := range out {
+		out[i].label = hookLabel(out[i].label, HookKindPost, i)
+	}
 	return out
 }
 
+func firstPriority(priority []int) int {
+	if len(priority) > 0 {
+		return priority[0]
+	}
+	return 0
+}
+
 // HookChain allows chaining multiple hooks together
 type HookChain struct {
 	preHooks  []PreHook
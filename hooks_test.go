@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
+	"testing/fstest"
 
 	"github.com/goliatone/go-template"
 	"github.com/goliatone/go-template/templatehooks"
@@ -893,3 +896,146 @@ func TestHooks_HelperFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestHookManager_NamedHooks(t *testing.T) {
+	manager := template.NewHooksManager()
+
+	var calls []string
+	record := func(name string) template.PreHook {
+		return func(ctx *template.HookContext) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	run := func() []string {
+		calls = nil
+		for _, hook := range manager.PreHooks() {
+			require.NoError(t, hook(&template.HookContext{}))
+		}
+		return calls
+	}
+
+	require.NoError(t, manager.AddNamedPreHook("defaults", record("defaults"), template.WithHookPriority(5)))
+	require.NoError(t, manager.AddNamedPreHook("validate", record("validate"), template.WithHookPriority(-1)))
+	manager.AddPreHook(record("anonymous"))
+	require.NoError(t, manager.AddNamedPostHook("header", func(ctx *template.HookContext) (string, error) {
+		return ctx.Output, nil
+	}))
+
+	err := manager.AddNamedPreHook("header", record("dup"))
+	require.ErrorIs(t, err, template.ErrHookExists)
+	require.Error(t, manager.AddNamedPreHook("", record("unnamed")))
+
+	require.Equal(t, []string{"validate", "anonymous", "defaults"}, run())
+	require.Equal(t, []template.HookInfo{
+		{Name: "validate", Kind: template.HookKindPre, Priority: -1, Enabled: true},
+		{Name: "", Kind: template.HookKindPre, Priority: 0, Enabled: true},
+		{Name: "defaults", Kind: template.HookKindPre, Priority: 5, Enabled: true},
+		{Name: "header", Kind: template.HookKindPost, Priority: 0, Enabled: true},
+	}, manager.List())
+
+	// Replace keeps the position.
+	require.NoError(t, manager.ReplacePreHook("validate", record("validate-v2")))
+	require.Equal(t, []string{"validate-v2", "anonymous", "defaults"}, run())
+	require.ErrorIs(t, manager.ReplacePreHook("missing", record("x")), template.ErrHookNotFound)
+	require.ErrorContains(t, manager.ReplacePreHook("header", record("x")), "is a post hook")
+
+	// Disabled hooks are listed but skipped.
+	require.NoError(t, manager.SetEnabled("defaults", false))
+	require.False(t, manager.Enabled("defaults"))
+	require.Equal(t, []string{"validate-v2", "anonymous"}, run())
+	require.False(t, manager.List()[2].Enabled)
+	require.NoError(t, manager.SetEnabled("defaults", true))
+	require.True(t, manager.Enabled("defaults"))
+	require.ErrorIs(t, manager.SetEnabled("missing", true), template.ErrHookNotFound)
+
+	require.True(t, manager.Remove("validate"))
+	require.False(t, manager.Remove("validate"))
+	require.Equal(t, []string{"anonymous", "defaults"}, run())
+
+	require.NoError(t, manager.AddNamedPreHook("late", record("late"), template.WithHookEnabled(false)))
+	require.False(t, manager.Enabled("late"))
+	require.Equal(t, []string{"anonymous", "defaults"}, run())
+}
+
+func TestEngine_ReplaceHooksDuringRender(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.tpl": {Data: []byte("x")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	suffix := func(s string) template.PostHook {
+		return func(ctx *template.HookContext) (string, error) {
+			return ctx.Output + s, nil
+		}
+	}
+	// yield lets a replace land while the render holds the hooks it read.
+	yield := func(ctx *template.HookContext) error {
+		runtime.Gosched()
+		return nil
+	}
+
+	require.NoError(t, renderer.RegisterNamedPreHook("defaults", yield))
+	require.NoError(t, renderer.RegisterNamedPostHook("suffix", suffix("a")))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 1000 {
+			require.NoError(t, renderer.ReplacePreHook("defaults", yield))
+			require.NoError(t, renderer.ReplacePostHook("suffix", suffix(fmt.Sprint(i%2))))
+		}
+	}()
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 250 {
+				out, err := renderer.RenderTemplate("page", nil)
+				require.NoError(t, err)
+				require.Contains(t, []string{"xa", "x0", "x1"}, out)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEngine_NamedHooks(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithBaseDir("testdata"))
+	require.NoError(t, err)
+
+	suffix := func(s string) template.PostHook {
+		return func(ctx *template.HookContext) (string, error) {
+			return ctx.Output + s, nil
+		}
+	}
+
+	require.NoError(t, renderer.RegisterNamedPostHook("b", suffix("b"), template.WithHookPriority(2)))
+	require.NoError(t, renderer.RegisterNamedPostHook("a", suffix("a"), template.WithHookPriority(1)))
+	renderer.RegisterPostHook(suffix("!"), 3)
+
+	out, err := renderer.RenderString("x", nil)
+	require.NoError(t, err)
+	require.Equal(t, "xab!", out)
+
+	require.NoError(t, renderer.ReplacePostHook("a", suffix("A")))
+	require.NoError(t, renderer.SetHookEnabled("b", false))
+	require.False(t, renderer.HookEnabled("b"))
+
+	out, err = renderer.RenderString("x", nil)
+	require.NoError(t, err)
+	require.Equal(t, "xA!", out)
+
+	require.True(t, renderer.RemoveHook("a"))
+	require.Len(t, renderer.Hooks(), 2)
+
+	// RegisterPreHook honours priority.
+	var order []int
+	renderer.RegisterPreHook(func(*template.HookContext) error { order = append(order, 2); return nil }, 2)
+	renderer.RegisterPreHook(func(*template.HookContext) error { order = append(order, 1); return nil }, 1)
+	_, err = renderer.RenderString("x", nil)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, order)
+}
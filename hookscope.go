@@ -0,0 +1,196 @@
+package template
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// HookScope restricts the renders a hook runs for. Every non empty criterion
+// must match, and a criterion matches when any of its values does. A zero
+// scope matches every render.
+//
+// Template names are the names passed to RenderTemplate or RenderFile, so
+// renders of RenderString, which have no name, only match scopes without
+// Templates, Namespaces and Extensions.
+type HookScope struct {
+	// Templates are path.Match patterns for the template name, with or
+	// without the template extension. Patterns without a "/" also match the
+	// base name, and a "**" segment matches any number of directories.
+	Templates []string
+	// Namespaces match the directories of the template name: "emails"
+	// matches "emails/welcome" and "emails/admin/reset".
+	Namespaces []string
+	// Extensions match the extension of the output path or, without one, of
+	// the template name once the template extension is stripped, e.g. ".go"
+	// for "model.go.tpl".
+	Extensions []string
+	Condition  HookCondition
+}
+
+// IsZero reports whether the scope matches every render.
+func (s HookScope) IsZero() bool {
+	return len(s.Templates) == 0 && len(s.Namespaces) == 0 && len(s.Extensions) == 0 && s.Condition == nil
+}
+
+// Matches reports whether a hook with this scope runs for ctx. The template
+// extension is read from ctx.Metadata["ext"], ".tpl" by default.
+func (s HookScope) Matches(ctx *HookContext) bool {
+	ext := ".tpl"
+	if v, ok := ctx.Metadata["ext"].(string); ok && v != "" {
+		ext = v
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+ctx.TemplateName), "/")
+	if ctx.TemplateName == "" {
+		name = ""
+	}
+	bare := strings.TrimSuffix(name, ext)
+
+	if len(s.Templates) > 0 && !matchAny(s.Templates, func(pattern string) bool {
+		return name != "" && (matchTemplate(pattern, name) || matchTemplate(pattern, bare))
+	}) {
+		return false
+	}
+
+	if len(s.Namespaces) > 0 && !matchAny(s.Namespaces, func(ns string) bool {
+		ns = strings.Trim(ns, "/")
+		return ns != "" && strings.HasPrefix(path.Dir(bare)+"/", ns+"/")
+	}) {
+		return false
+	}
+
+	if len(s.Extensions) > 0 {
+		target := ctx.OutputPath
+		if target == "" {
+			target = bare
+		}
+		got := strings.ToLower(path.Ext(target))
+		if !matchAny(s.Extensions, func(want string) bool {
+			want = strings.ToLower(want)
+			if !strings.HasPrefix(want, ".") {
+				want = "." + want
+			}
+			return got != "" && got == want
+		}) {
+			return false
+		}
+	}
+
+	return s.Condition == nil || s.Condition(ctx)
+}
+
+// String describes the scope for hook introspection.
+func (s HookScope) String() string {
+	if s.IsZero() {
+		return "all"
+	}
+
+	var parts []string
+	if len(s.Templates) > 0 {
+		parts = append(parts, "templates="+strings.Join(s.Templates, ","))
+	}
+	if len(s.Namespaces) > 0 {
+		parts = append(parts, "namespaces="+strings.Join(s.Namespaces, ","))
+	}
+	if len(s.Extensions) > 0 {
+		parts = append(parts, "extensions="+strings.Join(s.Extensions, ","))
+	}
+	if s.Condition != nil {
+		parts = append(parts, "condition")
+	}
+	return strings.Join(parts, " ")
+}
+
+// WithHookTemplates scopes the hook to template names matching any of the
+// patterns, see HookScope.Templates.
+func WithHookTemplates(patterns ...string) HookOption {
+	return func(e *hookEntry) {
+		e.scope.Templates = append(e.scope.Templates, patterns...)
+	}
+}
+
+// WithHookNamespaces scopes the hook to templates under any of the
+// namespaces, see HookScope.Namespaces.
+func WithHookNamespaces(namespaces ...string) HookOption {
+	return func(e *hookEntry) {
+		e.scope.Namespaces = append(e.scope.Namespaces, namespaces...)
+	}
+}
+
+// WithHookExtensions scopes the hook to outputs with any of the extensions,
+// see HookScope.Extensions.
+func WithHookExtensions(extensions ...string) HookOption {
+	return func(e *hookEntry) {
+		e.scope.Extensions = append(e.scope.Extensions, extensions...)
+	}
+}
+
+// WithHookCondition scopes the hook to renders the condition accepts.
+func WithHookCondition(condition HookCondition) HookOption {
+	return func(e *hookEntry) {
+		e.scope.Condition = condition
+	}
+}
+
+// WithHookScope sets the whole scope of the hook.
+func WithHookScope(scope HookScope) HookOption {
+	return func(e *hookEntry) {
+		e.scope = scope
+	}
+}
+
+func matchAny(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTemplate matches name against a path.Match pattern where a "**"
+// segment spans any number of directories.
+func matchTemplate(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func (s HookScope) validate() error {
+	for _, pattern := range s.Templates {
+		for _, segment := range strings.Split(pattern, "/") {
+			if segment == "**" {
+				continue
+			}
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid template pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package template_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookScope_Matches(t *testing.T) {
+	isMain := func(ctx *template.HookContext) bool { return ctx.Data == "main" }
+
+	tests := []struct {
+		name  string
+		scope template.HookScope
+		ctx   template.HookContext
+		want  bool
+	}{
+		{"zero scope", template.HookScope{}, template.HookContext{}, true},
+		{"base name glob", template.HookScope{Templates: []string{"*.go"}}, template.HookContext{TemplateName: "models/user.go"}, true},
+		{"glob with extension", template.HookScope{Templates: []string{"*.go.tpl"}}, template.HookContext{TemplateName: "models/user.go.tpl"}, true},
+		{"glob without extension", template.HookScope{Templates: []string{"models/*.go"}}, template.HookContext{TemplateName: "models/user.go.tpl"}, true},
+		{"glob other dir", template.HookScope{Templates: []string{"models/*.go"}}, template.HookContext{TemplateName: "api/user.go"}, false},
+		{"double star", template.HookScope{Templates: []string{"api/**/*.go"}}, template.HookContext{TemplateName: "api/v1/users/handler.go"}, true},
+		{"double star zero dirs", template.HookScope{Templates: []string{"api/**/*.go"}}, template.HookContext{TemplateName: "api/handler.go"}, true},
+		{"string render has no name", template.HookScope{Templates: []string{"*"}}, template.HookContext{}, false},
+		{"namespace", template.HookScope{Namespaces: []string{"emails"}}, template.HookContext{TemplateName: "emails/admin/reset"}, true},
+		{"nested namespace", template.HookScope{Namespaces: []string{"emails/admin"}}, template.HookContext{TemplateName: "emails/welcome"}, false},
+		{"namespace prefix only", template.HookScope{Namespaces: []string{"email"}}, template.HookContext{TemplateName: "emails/welcome"}, false},
+		{"extension from template", template.HookScope{Extensions: []string{"go"}}, template.HookContext{TemplateName: "model.go.tpl"}, true},
+		{"extension custom template ext", template.HookScope{Extensions: []string{".go"}}, template.HookContext{TemplateName: "model.go.j2", Metadata: map[string]any{"ext": ".j2"}}, true},
+		{"extension from output path", template.HookScope{Extensions: []string{".yaml", ".yml"}}, template.HookContext{TemplateName: "config", OutputPath: "deploy/app.yml"}, true},
+		{"extension mismatch", template.HookScope{Extensions: []string{".go"}}, template.HookContext{TemplateName: "readme.md"}, false},
+		{"condition", template.HookScope{Condition: isMain}, template.HookContext{Data: "main"}, true},
+		{"all criteria", template.HookScope{Namespaces: []string{"cmd"}, Extensions: []string{".go"}, Condition: isMain}, template.HookContext{TemplateName: "cmd/app.go", Data: "lib"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.scope.Matches(&tt.ctx))
+		})
+	}
+}
+
+func TestHookManager_ScopedHooks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"models/user.go.tpl":  {Data: []byte("package models")},
+		"emails/welcome.tpl":  {Data: []byte("Welcome")},
+		"config/app.yaml.tpl": {Data: []byte("app: true")},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	prefix := func(s string) template.PostHook {
+		return func(ctx *template.HookContext) (string, error) {
+			return s + ctx.Output, nil
+		}
+	}
+
+	require.NoError(t, renderer.RegisterNamedPostHook("go-header", prefix("// go\n"), template.WithHookExtensions(".go")))
+	require.NoError(t, renderer.RegisterNamedPostHook("email-footer", func(ctx *template.HookContext) (string, error) {
+		return ctx.Output + "\n-- team", nil
+	}, template.WithHookNamespaces("emails")))
+	require.NoError(t, renderer.RegisterNamedPostHook("yaml-header", prefix("# yaml\n"), template.WithHookTemplates("config/*.yaml")))
+
+	var validated []string
+	require.NoError(t, renderer.RegisterNamedPreHook("validate", func(ctx *template.HookContext) error {
+		validated = append(validated, ctx.TemplateName)
+		return nil
+	}, template.WithHookTemplates("models/**")))
+
+	out, err := renderer.RenderTemplate("models/user.go", nil)
+	require.NoError(t, err)
+	require.Equal(t, "// go\npackage models", out)
+
+	out, err = renderer.RenderTemplate("emails/welcome", nil)
+	require.NoError(t, err)
+	require.Equal(t, "Welcome\n-- team", out)
+
+	out, err = renderer.RenderTemplate("config/app.yaml", nil)
+	require.NoError(t, err)
+	require.Equal(t, "# yaml\napp: true", out)
+
+	out, err = renderer.RenderString("{{ 1 }}", nil)
+	require.NoError(t, err)
+	require.Equal(t, "1", out)
+
+	require.Equal(t, []string{"models/user.go"}, validated)
+
+	infos := renderer.Hooks()
+	require.Len(t, infos, 4)
+	require.Equal(t, "validate", infos[0].Name)
+	require.Equal(t, "templates=models/**", infos[0].Scope.String())
+	require.Equal(t, "extensions=.go", infos[1].Scope.String())
+	require.Equal(t, "namespaces=emails", infos[2].Scope.String())
+
+	err = renderer.RegisterNamedPostHook("bad", prefix(""), template.WithHookTemplates("[a-"))
+	require.ErrorContains(t, err, `invalid template pattern "[a-"`)
+}
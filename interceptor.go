@@ -0,0 +1,87 @@
+package template
+
+import (
+	"time"
+)
+
+// RenderRequest describes a render passing through the interceptor chain.
+// Interceptors may change it before calling the next RenderFunc, and find it
+// updated with the changes hooks made once the call returns.
+type RenderRequest struct {
+	// TemplateName is the template name, empty for RenderString.
+	TemplateName string
+	// Template is the template content of a RenderString render.
+	Template string
+	Data     any
+	// OutputPath is the output path of a RenderFile render.
+	OutputPath string
+	// Metadata is shared with every hook of the render.
+	Metadata map[string]any
+}
+
+// RenderFunc renders a request and returns its output.
+type RenderFunc func(req *RenderRequest) (string, error)
+
+// Interceptor wraps a render. It may act before and after calling next, or
+// return without calling it, e.g. to serve a cached output. Interceptors run
+// around pre hooks, the template and post hooks; error hooks see the error an
+// interceptor returns, and output is written to the render writers after the
+// chain returns.
+type Interceptor func(next RenderFunc) RenderFunc
+
+// WithInterceptors adds interceptors to the engine, see Engine.Use.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(e *Engine) {
+		e.interceptors = append(e.interceptors, interceptors...)
+	}
+}
+
+// Use adds interceptors around RenderTemplate, RenderString and RenderFile.
+// The first interceptor added is the outermost one.
+func (r *Engine) Use(interceptors ...Interceptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interceptors = append(r.interceptors, interceptors...)
+}
+
+// intercept wraps render with the engine interceptors.
+func (r *Engine) intercept(render RenderFunc) RenderFunc {
+	r.mu.RLock()
+	interceptors := append([]Interceptor(nil), r.interceptors...)
+	r.mu.RUnlock()
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		render = interceptors[i](render)
+	}
+	return render
+}
+
+// RecoverInterceptor returns an interceptor that turns a panic in the rest of
+// the chain into a *PanicError. The engine already recovers panics in hooks and
+// template execution; this covers the interceptors themselves.
+func RecoverInterceptor() Interceptor {
+	return func(next RenderFunc) RenderFunc {
+		return func(req *RenderRequest) (output string, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					output = ""
+					err = newPanicError(v, "", req.TemplateName)
+				}
+			}()
+			return next(req)
+		}
+	}
+}
+
+// TimingInterceptor returns an interceptor that reports the duration and
+// result of every render to observe.
+func TimingInterceptor(observe func(req *RenderRequest, elapsed time.Duration, err error)) Interceptor {
+	return func(next RenderFunc) RenderFunc {
+		return func(req *RenderRequest) (string, error) {
+			start := time.Now()
+			output, err := next(req)
+			observe(req, time.Since(start), err)
+			return output, err
+		}
+	}
+}
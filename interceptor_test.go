@@ -0,0 +1,162 @@
+package template_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_InterceptorOrder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello.tpl": {Data: []byte("Hello {{ name }}")},
+	}
+
+	var calls []string
+	trace := func(label string) template.Interceptor {
+		return func(next template.RenderFunc) template.RenderFunc {
+			return func(req *template.RenderRequest) (string, error) {
+				calls = append(calls, label+" before")
+				output, err := next(req)
+				calls = append(calls, label+" after")
+				return output, err
+			}
+		}
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys), template.WithInterceptors(trace("outer")))
+	require.NoError(t, err)
+	renderer.Use(trace("inner"))
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		calls = append(calls, "pre hook")
+		return nil
+	})
+
+	output, err := renderer.RenderTemplate("hello", map[string]any{"name": "World"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", output)
+	require.Equal(t, []string{"outer before", "inner before", "pre hook", "inner after", "outer after"}, calls)
+}
+
+func TestEngine_InterceptorShortCircuit(t *testing.T) {
+	cache := map[string]string{}
+	cached := func(next template.RenderFunc) template.RenderFunc {
+		return func(req *template.RenderRequest) (string, error) {
+			if output, ok := cache[req.Template]; ok {
+				return output, nil
+			}
+			output, err := next(req)
+			if err == nil {
+				cache[req.Template] = output
+			}
+			return output, err
+		}
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{}), template.WithInterceptors(cached))
+	require.NoError(t, err)
+
+	renders := 0
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		renders++
+		return nil
+	})
+
+	var buf bytes.Buffer
+	for range 2 {
+		buf.Reset()
+		output, err := renderer.RenderString("{{ 1 + 1 }}", nil, &buf)
+		require.NoError(t, err)
+		require.Equal(t, "2", output)
+		require.Equal(t, "2", buf.String())
+	}
+	require.Equal(t, 1, renders)
+}
+
+func TestEngine_InterceptorSeesHookChanges(t *testing.T) {
+	fsys := fstest.MapFS{
+		"model.tpl": {Data: []byte("package {{ pkg }}")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	var seen string
+	renderer.Use(func(next template.RenderFunc) template.RenderFunc {
+		return func(req *template.RenderRequest) (string, error) {
+			req.Data = map[string]any{"pkg": "models"}
+			output, err := next(req)
+			seen = req.OutputPath
+			return output, err
+		}
+	})
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		ctx.OutputPath = "out/" + ctx.OutputPath
+		return nil
+	})
+
+	file, err := renderer.RenderFile("model", nil, template.WithOutputPath("model.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package models", string(file.Content))
+	require.Equal(t, "out/model.go", file.Path)
+	require.Equal(t, "out/model.go", seen)
+}
+
+func TestRecoverInterceptor(t *testing.T) {
+	renderer, err := template.NewRenderer(
+		template.WithFS(fstest.MapFS{}),
+		template.WithInterceptors(template.RecoverInterceptor(), func(next template.RenderFunc) template.RenderFunc {
+			return func(req *template.RenderRequest) (string, error) {
+				panic("boom")
+			}
+		}),
+	)
+	require.NoError(t, err)
+
+	var handled *template.RenderError
+	require.NoError(t, renderer.OnError(func(ctx *template.HookContext, err *template.RenderError) (string, bool) {
+		handled = err
+		return "", false
+	}))
+
+	_, err = renderer.RenderString("text", nil)
+	require.Error(t, err)
+
+	var panicErr *template.PanicError
+	require.True(t, errors.As(err, &panicErr))
+	require.Equal(t, "boom", panicErr.Value)
+	require.NotEmpty(t, panicErr.Stack)
+	require.NotNil(t, handled)
+	require.EqualError(t, err, "render panicked: boom")
+}
+
+func TestTimingInterceptor(t *testing.T) {
+	fsys := fstest.MapFS{
+		"broken.tpl": {Data: []byte("{% if %}")},
+		"ok.tpl":     {Data: []byte("fine")},
+	}
+
+	var timed []string
+	renderer, err := template.NewRenderer(
+		template.WithFS(fsys),
+		template.WithInterceptors(template.TimingInterceptor(func(req *template.RenderRequest, elapsed time.Duration, err error) {
+			require.GreaterOrEqual(t, elapsed, time.Duration(0))
+			status := "ok"
+			if err != nil {
+				status = "failed"
+			}
+			timed = append(timed, req.TemplateName+" "+status)
+		})),
+	)
+	require.NoError(t, err)
+
+	_, err = renderer.RenderTemplate("ok", nil)
+	require.NoError(t, err)
+	_, err = renderer.RenderTemplate("broken", nil)
+	require.Error(t, err)
+
+	require.Equal(t, []string{"ok ok", "broken failed"}, timed)
+}
@@ -0,0 +1,202 @@
+package template
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// RenderPhase names the step of a render an error occurred in.
+type RenderPhase string
+
+const (
+	PhasePreHook  RenderPhase = "pre-hook"
+	PhaseLoad     RenderPhase = "load"
+	PhaseData     RenderPhase = "data"
+	PhaseExecute  RenderPhase = "execute"
+	PhasePostHook RenderPhase = "post-hook"
+	PhaseWrite    RenderPhase = "write"
+	// PhaseAfterRender is reported when an after render hook panics.
+	PhaseAfterRender RenderPhase = "after-render"
+)
+
+// RenderError is returned by RenderTemplate, RenderString and RenderFile when
+// a render fails. Its message is the message of the underlying error.
+type RenderError struct {
+	Phase RenderPhase
+	// TemplateName is empty for RenderString.
+	TemplateName string
+	Err          error
+}
+
+func (e *RenderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+func renderError(phase RenderPhase, name string, err error) error {
+	return &RenderError{Phase: phase, TemplateName: name, Err: err}
+}
+
+// LoadHook runs once a template is compiled: the first time a template file
+// is loaded, and for every RenderString call. ctx.TemplateName is the template
+// name without extension, or empty for strings, in which case ctx.Template
+// holds the template content. Parsed front matter is in ctx.Metadata. A template
+// whose load hook panics is not cached, so the next render loads it again.
+type LoadHook func(ctx *HookContext)
+
+// ErrorHook runs when a render fails, with ctx describing the render as far as
+// it got. Returning ok substitutes fallback for the output: the render then
+// succeeds, skipping the remaining error hooks and any post hooks not yet run.
+type ErrorHook func(ctx *HookContext, err *RenderError) (fallback string, ok bool)
+
+// WriteHook runs after the output was written to one of the render writers,
+// with the result of the write.
+type WriteHook func(ctx *HookContext, w io.Writer, n int, err error)
+
+// AfterRenderHook runs when a render ends, successfully or not, with its
+// duration. ctx.Output holds the final output.
+type AfterRenderHook func(ctx *HookContext, elapsed time.Duration, err error)
+
+// OnLoad registers a hook that runs when templates are compiled.
+func (e *HookManager) OnLoad(hook LoadHook, opts ...HookOption) error {
+	return e.addEntry(&hookEntry{kind: HookKindLoad, enabled: true, load: hook}, opts)
+}
+
+// OnError registers a hook that runs when a render fails.
+func (e *HookManager) OnError(hook ErrorHook, opts ...HookOption) error {
+	return e.addEntry(&hookEntry{kind: HookKindError, enabled: true, onError: hook}, opts)
+}
+
+// OnWrite registers a hook that runs after each write to a render writer.
+func (e *HookManager) OnWrite(hook WriteHook, opts ...HookOption) error {
+	return e.addEntry(&hookEntry{kind: HookKindWrite, enabled: true, write: hook}, opts)
+}
+
+// AfterRender registers a hook that runs when a render ends.
+func (e *HookManager) AfterRender(hook AfterRenderHook, opts ...HookOption) error {
+	return e.addEntry(&hookEntry{kind: HookKindAfterRender, enabled: true, after: hook}, opts)
+}
+
+// lifecycle returns copies of the enabled entries of kind whose scope matches
+// ctx.
+func (e *HookManager) lifecycle(kind HookKind, ctx *HookContext) []hookEntry {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var out []hookEntry
+	for _, entry := range e.entries {
+		if entry.kind == kind && entry.enabled && entry.scope.Matches(ctx) {
+			out = append(out, *entry)
+		}
+	}
+	return out
+}
+
+// OnLoad registers a hook that runs when templates are compiled, see
+// HookManager.OnLoad.
+func (e *Engine) OnLoad(hook LoadHook, opts ...HookOption) error {
+	return e.hooks.OnLoad(hook, opts...)
+}
+
+// OnError registers a hook that runs when a render fails, see
+// HookManager.OnError.
+func (e *Engine) OnError(hook ErrorHook, opts ...HookOption) error {
+	return e.hooks.OnError(hook, opts...)
+}
+
+// OnWrite registers a hook that runs after each write to a render writer, see
+// HookManager.OnWrite.
+func (e *Engine) OnWrite(hook WriteHook, opts ...HookOption) error {
+	return e.hooks.OnWrite(hook, opts...)
+}
+
+// AfterRender registers a hook that runs when a render ends, see
+// HookManager.AfterRender.
+func (e *Engine) AfterRender(hook AfterRenderHook, opts ...HookOption) error {
+	return e.hooks.AfterRender(hook, opts...)
+}
+
+// runLoadHooks runs the load hooks for ctx. A panicking hook stops the
+// remaining ones and is returned as a *PanicError.
+func (r *Engine) runLoadHooks(ctx *HookContext) error {
+	for i, entry := range r.hooks.lifecycle(HookKindLoad, ctx) {
+		err := guard(hookLabel(entry.name, HookKindLoad, i), ctx.TemplateName, func() error {
+			entry.load(ctx)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishRender runs error hooks when err is set, writes the output and runs
+// after render hooks. A panicking lifecycle hook fails the render.
+func (r *Engine) finishRender(ctx *HookContext, err error, start time.Time, out []io.Writer) (string, error) {
+	if err != nil {
+		var renderErr *RenderError
+		if !errors.As(err, &renderErr) {
+			renderErr = &RenderError{Phase: PhaseExecute, TemplateName: ctx.TemplateName, Err: err}
+			err = renderErr
+		}
+
+		for i, entry := range r.hooks.lifecycle(HookKindError, ctx) {
+			var (
+				fallback string
+				ok       bool
+			)
+			if perr := guard(hookLabel(entry.name, HookKindError, i), ctx.TemplateName, func() error {
+				fallback, ok = entry.onError(ctx, renderErr)
+				return nil
+			}); perr != nil {
+				err = renderError(renderErr.Phase, ctx.TemplateName, perr)
+				break
+			}
+			if ok {
+				ctx.Output, err = fallback, nil
+				break
+			}
+		}
+	}
+
+	if err == nil {
+		for _, w := range out {
+			n, werr := w.Write([]byte(ctx.Output))
+			for i, entry := range r.hooks.lifecycle(HookKindWrite, ctx) {
+				if perr := guard(hookLabel(entry.name, HookKindWrite, i), ctx.TemplateName, func() error {
+					entry.write(ctx, w, n, werr)
+					return nil
+				}); perr != nil && werr == nil {
+					werr = perr
+				}
+			}
+			if werr != nil {
+				err = renderError(PhaseWrite, ctx.TemplateName, werr)
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		ctx.Output = ""
+	}
+	for i, entry := range r.hooks.lifecycle(HookKindAfterRender, ctx) {
+		elapsed := time.Since(start)
+		if perr := guard(hookLabel(entry.name, HookKindAfterRender, i), ctx.TemplateName, func() error {
+			entry.after(ctx, elapsed, err)
+			return nil
+		}); perr != nil && err == nil {
+			ctx.Output, err = "", renderError(PhaseAfterRender, ctx.TemplateName, perr)
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return ctx.Output, nil
+}
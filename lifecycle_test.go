@@ -0,0 +1,174 @@
+package template_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestEngine_OnLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.tpl": {Data: []byte("---\ndescription: A page\n---\nHello")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	var loaded []string
+	require.NoError(t, renderer.OnLoad(func(ctx *template.HookContext) {
+		name := ctx.TemplateName
+		if name == "" {
+			name = "string:" + ctx.Template
+		}
+		if meta, ok := ctx.Metadata[template.MetadataFrontMatterKey].(*template.TemplateMetadata); ok {
+			name += " (" + meta.Description + ")"
+		}
+		loaded = append(loaded, name)
+	}))
+
+	for range 2 {
+		_, err = renderer.RenderTemplate("page", nil)
+		require.NoError(t, err)
+	}
+	_, err = renderer.RenderString("{{ 1 }}", nil)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"page (A page)", "string:{{ 1 }}"}, loaded)
+}
+
+func TestEngine_OnLoadFailsRenderTemplate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.tpl": {Data: []byte("---\ndescription: A page\n---\nhi")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	var seen *template.TemplateMetadata
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		seen, _ = ctx.Metadata[template.MetadataFrontMatterKey].(*template.TemplateMetadata)
+		return nil
+	})
+	require.NoError(t, renderer.OnLoad(func(ctx *template.HookContext) {
+		panic("invalid template")
+	}))
+
+	out, err := renderer.RenderTemplate("page", nil)
+	require.Empty(t, out)
+	var renderErr *template.RenderError
+	require.ErrorAs(t, err, &renderErr)
+	require.Equal(t, template.PhaseLoad, renderErr.Phase)
+	require.NotNil(t, seen, "pre hooks still see the front matter")
+	require.Equal(t, "A page", seen.Description)
+}
+
+func TestEngine_OnError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"broken.tpl": {Data: []byte("{% if %}")},
+		"ok.tpl":     {Data: []byte("fine")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		if ctx.Data == "reject" {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+
+	var phases []template.RenderPhase
+	require.NoError(t, renderer.OnError(func(ctx *template.HookContext, err *template.RenderError) (string, bool) {
+		phases = append(phases, err.Phase)
+		return "", false
+	}))
+
+	_, err = renderer.RenderTemplate("broken", nil)
+	var renderErr *template.RenderError
+	require.ErrorAs(t, err, &renderErr)
+	require.Equal(t, template.PhaseLoad, renderErr.Phase)
+	require.Equal(t, "broken", renderErr.TemplateName)
+
+	_, err = renderer.RenderTemplate("ok", "reject")
+	require.ErrorContains(t, err, "pre-hook failed: rejected")
+
+	_, err = renderer.RenderString("{{ x|no_such_filter }}", nil)
+	require.Error(t, err)
+
+	require.Equal(t, []template.RenderPhase{template.PhaseLoad, template.PhasePreHook, template.PhaseLoad}, phases)
+
+	// A fallback turns the failure into a successful render.
+	require.NoError(t, renderer.OnError(func(ctx *template.HookContext, err *template.RenderError) (string, bool) {
+		return "fallback for " + ctx.TemplateName, true
+	}, template.WithHookName("fallback")))
+
+	var buf bytes.Buffer
+	out, err := renderer.RenderTemplate("broken", nil, &buf)
+	require.NoError(t, err)
+	require.Equal(t, "fallback for broken", out)
+	require.Equal(t, out, buf.String())
+}
+
+func TestEngine_OnWriteAndAfterRender(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{}))
+	require.NoError(t, err)
+
+	type write struct {
+		n   int
+		err string
+	}
+	var writes []write
+	require.NoError(t, renderer.OnWrite(func(ctx *template.HookContext, w io.Writer, n int, err error) {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		writes = append(writes, write{n, msg})
+	}))
+
+	type result struct {
+		output string
+		err    error
+	}
+	var results []result
+	require.NoError(t, renderer.AfterRender(func(ctx *template.HookContext, elapsed time.Duration, err error) {
+		require.GreaterOrEqual(t, elapsed, time.Duration(0))
+		results = append(results, result{ctx.Output, err})
+	}))
+
+	var a, b bytes.Buffer
+	out, err := renderer.RenderString("hello", nil, &a, &b)
+	require.NoError(t, err)
+	require.Equal(t, "hello", out)
+	require.Equal(t, []write{{5, ""}, {5, ""}}, writes)
+
+	_, err = renderer.RenderString("hello", nil, failingWriter{}, &a)
+	var renderErr *template.RenderError
+	require.ErrorAs(t, err, &renderErr)
+	require.Equal(t, template.PhaseWrite, renderErr.Phase)
+	require.EqualError(t, err, "disk full")
+	require.Equal(t, write{0, "disk full"}, writes[2])
+	require.Len(t, writes, 3)
+
+	require.Len(t, results, 2)
+	require.Equal(t, "hello", results[0].output)
+	require.NoError(t, results[0].err)
+	require.Equal(t, "", results[1].output)
+	require.ErrorAs(t, results[1].err, &renderErr)
+
+	kinds := []template.HookKind{}
+	for _, info := range renderer.Hooks() {
+		kinds = append(kinds, info.Kind)
+	}
+	require.Equal(t, []template.HookKind{template.HookKindWrite, template.HookKindAfterRender}, kinds)
+}
@@ -0,0 +1,206 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultLockfileName is the conventional lockfile name in an output directory.
+const DefaultLockfileName = ".go-template.lock"
+
+// lockfileVersion is bumped when the lockfile format changes.
+const lockfileVersion = 1
+
+// Lockfile records how every generated file was produced.
+type Lockfile struct {
+	Version int         `json:"version"`
+	Files   []LockEntry `json:"files"`
+}
+
+// LockEntry describes one generated file. Hashes are "sha256:<hex>" strings.
+type LockEntry struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+	// DataHash hashes the render data, encoded as JSON with sorted keys.
+	DataHash string `json:"data_hash"`
+	// TemplateHash hashes the template source, or the asset for copied files.
+	// Templates pulled in through extends or include are not covered.
+	TemplateHash string `json:"template_hash"`
+	// Checksum hashes the file content, as written to the output directory.
+	Checksum string `json:"checksum"`
+}
+
+// Lock builds a lockfile for files generated from data, hashing their sources
+// in the engine file system. Checksums hash file.Content, so when the write
+// merged or kept files pass them with their content on disk.
+func (r *Engine) Lock(files []GeneratedFile, data any) (*Lockfile, error) {
+	fsys, err := r.SourceFS()
+	if err != nil {
+		return nil, err
+	}
+
+	dataHash, err := hashData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lockfile{Version: lockfileVersion, Files: make([]LockEntry, 0, len(files))}
+	for _, file := range files {
+		source, err := fs.ReadFile(fsys, file.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash template of %s: %w", file.Path, err)
+		}
+
+		lock.Files = append(lock.Files, LockEntry{
+			Path:         file.Path,
+			Template:     file.Source,
+			DataHash:     dataHash,
+			TemplateHash: hashBytes(source),
+			Checksum:     hashBytes(file.Content),
+		})
+	}
+
+	sort.Slice(lock.Files, func(i, j int) bool {
+		return lock.Files[i].Path < lock.Files[j].Path
+	})
+
+	return lock, nil
+}
+
+// ReadLockfile reads a lockfile written by Lockfile.Write.
+func ReadLockfile(p string) (*Lockfile, error) {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read lockfile: %w", err)
+	}
+
+	lock := &Lockfile{}
+	if err := json.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %w", p, err)
+	}
+	if lock.Version != lockfileVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %d in %s", lock.Version, p)
+	}
+
+	return lock, nil
+}
+
+// Write stores the lockfile as indented JSON at p.
+func (l *Lockfile) Write(p string) error {
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, append(content, '\n'), 0o644)
+}
+
+// LockIssueKind classifies a problem reported by CheckLock.
+type LockIssueKind string
+
+const (
+	// LockDrifted marks a file edited since it was generated.
+	LockDrifted LockIssueKind = "drifted"
+	// LockMissing marks a generated file that no longer exists.
+	LockMissing LockIssueKind = "missing"
+	// LockStale marks a file whose template or data changed since generation.
+	LockStale LockIssueKind = "stale"
+)
+
+// LockIssue is one problem found by CheckLock.
+type LockIssue struct {
+	Path   string
+	Kind   LockIssueKind
+	Reason string
+}
+
+func (i LockIssue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Path, i.Kind, i.Reason)
+}
+
+// LockCheckError lists the issues found by CheckLock.
+type LockCheckError struct {
+	Issues []LockIssue
+}
+
+func (e *LockCheckError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = issue.String()
+	}
+	return fmt.Sprintf("%d generated files out of date:\n  %s", len(e.Issues), strings.Join(lines, "\n  "))
+}
+
+// CheckLock compares the files under dest and the current templates and data
+// against lock. Files edited by hand are reported as drifted; files whose
+// template or data changed, so that regenerating would change them, as stale.
+// It returns a *LockCheckError when any issue is found, making it suitable as
+// a CI gate.
+func (r *Engine) CheckLock(dest string, lock *Lockfile, data any) ([]LockIssue, error) {
+	fsys, err := r.SourceFS()
+	if err != nil {
+		return nil, err
+	}
+
+	dataHash, err := hashData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []LockIssue
+	for _, entry := range lock.Files {
+		content, err := readExisting(dest, entry.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case content == nil:
+			issues = append(issues, LockIssue{Path: entry.Path, Kind: LockMissing, Reason: "file does not exist"})
+		case hashBytes(content) != entry.Checksum:
+			issues = append(issues, LockIssue{Path: entry.Path, Kind: LockDrifted, Reason: "content differs from generated output"})
+		}
+
+		source, err := fs.ReadFile(fsys, entry.Template)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			issues = append(issues, LockIssue{Path: entry.Path, Kind: LockStale, Reason: fmt.Sprintf("template %s no longer exists", entry.Template)})
+		case err != nil:
+			return nil, fmt.Errorf("failed to hash template of %s: %w", entry.Path, err)
+		case hashBytes(source) != entry.TemplateHash:
+			issues = append(issues, LockIssue{Path: entry.Path, Kind: LockStale, Reason: fmt.Sprintf("template %s changed", entry.Template)})
+		case dataHash != entry.DataHash:
+			issues = append(issues, LockIssue{Path: entry.Path, Kind: LockStale, Reason: "render data changed"})
+		}
+	}
+
+	if len(issues) > 0 {
+		return issues, &LockCheckError{Issues: issues}
+	}
+	return nil, nil
+}
+
+func hashBytes(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// hashData hashes data through its JSON form, which sorts map keys.
+func hashData(data any) (string, error) {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash data: %w", err)
+	}
+	return hashBytes(content), nil
+}
@@ -0,0 +1,92 @@
+package template_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockfile_Check(t *testing.T) {
+	src := writeTemplates(t, map[string]string{
+		"tree/model.go.tpl": "package {{ pkg }}\n",
+		"tree/README.md":    "readme\n",
+		"tree/doc.txt.tpl":  "docs for {{ pkg }}\n",
+	})
+	dest := t.TempDir()
+	data := map[string]any{"pkg": "models"}
+
+	renderer, err := template.NewRenderer(template.WithBaseDir(src))
+	require.NoError(t, err)
+
+	files, err := renderer.Scaffold("tree", dest, data)
+	require.NoError(t, err)
+
+	lock, err := renderer.Lock(files, data)
+	require.NoError(t, err)
+	require.Len(t, lock.Files, 3)
+	require.Equal(t, "README.md", lock.Files[0].Path)
+	require.Equal(t, "tree/README.md", lock.Files[0].Template)
+	require.Regexp(t, `^sha256:[0-9a-f]{64}$`, lock.Files[0].Checksum)
+
+	lockPath := filepath.Join(dest, template.DefaultLockfileName)
+	require.NoError(t, lock.Write(lockPath))
+	lock, err = template.ReadLockfile(lockPath)
+	require.NoError(t, err)
+
+	issues, err := renderer.CheckLock(dest, lock, data)
+	require.NoError(t, err)
+	require.Empty(t, issues)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "model.go"), []byte("package edited\n"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(dest, "doc.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "tree", "README.md"), []byte("new readme\n"), 0o644))
+
+	issues, err = renderer.CheckLock(dest, lock, data)
+	var checkErr *template.LockCheckError
+	require.True(t, errors.As(err, &checkErr))
+	require.Equal(t, []template.LockIssue{
+		{Path: "README.md", Kind: template.LockStale, Reason: "template tree/README.md changed"},
+		{Path: "doc.txt", Kind: template.LockMissing, Reason: "file does not exist"},
+		{Path: "model.go", Kind: template.LockDrifted, Reason: "content differs from generated output"},
+	}, issues)
+	require.Contains(t, err.Error(), "3 generated files out of date")
+
+	issues, _ = renderer.CheckLock(dest, lock, map[string]any{"pkg": "other"})
+	require.Contains(t, issues, template.LockIssue{Path: "model.go", Kind: template.LockStale, Reason: "render data changed"})
+}
+
+func TestEngine_LockRenderFile(t *testing.T) {
+	src := writeTemplates(t, map[string]string{
+		"model.go.tpl": "package {{ pkg }}\n",
+	})
+	data := map[string]any{"pkg": "models"}
+
+	renderer, err := template.NewRenderer(template.WithBaseDir(src))
+	require.NoError(t, err)
+
+	file, err := renderer.RenderFile("model.go", data, template.WithOutputPath("model.go"))
+	require.NoError(t, err)
+	require.Equal(t, "model.go.tpl", file.Source)
+
+	lock, err := renderer.Lock([]template.GeneratedFile{file}, data)
+	require.NoError(t, err)
+	require.Len(t, lock.Files, 1)
+	require.Equal(t, "model.go.tpl", lock.Files[0].Template)
+}
+
+func TestReadLockfile_Invalid(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"bad.lock": "{",
+		"old.lock": `{"version": 99, "files": []}`,
+	})
+
+	_, err := template.ReadLockfile(filepath.Join(dir, "bad.lock"))
+	require.ErrorContains(t, err, "invalid lockfile")
+
+	_, err = template.ReadLockfile(filepath.Join(dir, "old.lock"))
+	require.ErrorContains(t, err, "unsupported lockfile version 99")
+}
@@ -0,0 +1,243 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Conflict markers written by ThreeWayMerge.
+const (
+	ConflictStart     = "<<<<<<< current"
+	ConflictSeparator = "======="
+	ConflictEnd       = ">>>>>>> generated"
+)
+
+// ThreeWayMerge merges the edits made to the previously generated base on disk
+// (current) with the freshly generated output. Regions changed on one side
+// only are taken from that side; regions changed differently on both sides
+// are written between conflict markers. It returns the merged content and the
+// number of conflicts.
+func ThreeWayMerge(base, current, generated []byte) ([]byte, int) {
+	baseLines := splitKeepEOL(base)
+	currentLines := splitKeepEOL(current)
+	generatedLines := splitKeepEOL(generated)
+
+	toCurrent := matchedLines(baseLines, currentLines)
+	toGenerated := matchedLines(baseLines, generatedLines)
+
+	var out bytes.Buffer
+	conflicts := 0
+	i, j, k := 0, 0, 0
+
+	for {
+		// Find the next base line kept unchanged on both sides.
+		sync := -1
+		for n := i; n < len(baseLines); n++ {
+			jn, okC := toCurrent[n]
+			kn, okG := toGenerated[n]
+			if okC && okG && jn >= j && kn >= k {
+				sync = n
+				break
+			}
+		}
+
+		endBase, endCurrent, endGenerated := len(baseLines), len(currentLines), len(generatedLines)
+		if sync >= 0 {
+			endBase, endCurrent, endGenerated = sync, toCurrent[sync], toGenerated[sync]
+		}
+
+		if mergeChunk(&out, baseLines[i:endBase], currentLines[j:endCurrent], generatedLines[k:endGenerated]) {
+			conflicts++
+		}
+
+		if sync < 0 {
+			break
+		}
+
+		out.WriteString(baseLines[sync])
+		i, j, k = sync+1, endCurrent+1, endGenerated+1
+	}
+
+	return out.Bytes(), conflicts
+}
+
+// mergeChunk writes the resolution of one unstable chunk and reports whether
+// it conflicted.
+func mergeChunk(out *bytes.Buffer, base, current, generated []string) bool {
+	switch {
+	case equalLines(current, base):
+		writeLines(out, generated)
+	case equalLines(generated, base), equalLines(current, generated):
+		writeLines(out, current)
+	default:
+		out.WriteString(ConflictStart + "\n")
+		writeLines(out, current)
+		ensureNewline(out)
+		out.WriteString(ConflictSeparator + "\n")
+		writeLines(out, generated)
+		ensureNewline(out)
+		out.WriteString(ConflictEnd + "\n")
+		return true
+	}
+	return false
+}
+
+func matchedLines(a, b []string) map[int]int {
+	matches := make(map[int]int)
+	for _, block := range difflib.NewMatcher(a, b).GetMatchingBlocks() {
+		for n := 0; n < block.Size; n++ {
+			matches[block.A+n] = block.B + n
+		}
+	}
+	return matches
+}
+
+func splitKeepEOL(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(out *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+func ensureNewline(out *bytes.Buffer) {
+	if out.Len() > 0 && out.Bytes()[out.Len()-1] != '\n' {
+		out.WriteByte('\n')
+	}
+}
+
+// MergeStatus is the outcome of merging one file.
+type MergeStatus string
+
+const (
+	MergeCreated   MergeStatus = "created"
+	MergeUpdated   MergeStatus = "updated"
+	MergeMerged    MergeStatus = "merged"
+	MergeUnchanged MergeStatus = "unchanged"
+	MergeConflict  MergeStatus = "conflict"
+)
+
+// MergeResult reports what MergeFiles did to one file.
+type MergeResult struct {
+	Path      string
+	Status    MergeStatus
+	Conflicts int
+	// Content is the merged content written, or that would have been written.
+	Content []byte
+}
+
+// MergeConflictError is returned by MergeFiles when conflicts are not allowed.
+type MergeConflictError struct {
+	Paths []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("merge conflicts in %s", strings.Join(e.Paths, ", "))
+}
+
+// MergeOption configures MergeFiles.
+type MergeOption func(*mergeConfig)
+
+type mergeConfig struct {
+	failOnConflict bool
+}
+
+// WithMergeFailOnConflict makes MergeFiles return a *MergeConflictError and
+// write nothing when any file conflicts, instead of writing conflict markers.
+func WithMergeFailOnConflict(enabled bool) MergeOption {
+	return func(c *mergeConfig) {
+		c.failOnConflict = enabled
+	}
+}
+
+// MergeFiles writes generated files under dest, merging them with edits made
+// since the previous generation. stateDir stores the output of the previous
+// generation, mirroring dest, and receives the new output once dest has been
+// written. A file missing from the state dir is merged against an empty base,
+// so differing content conflicts. Every file is merged before anything is
+// written.
+func MergeFiles(dest, stateDir string, files []GeneratedFile, opts ...MergeOption) ([]MergeResult, error) {
+	var cfg mergeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	results := make([]MergeResult, 0, len(files))
+	var conflicted []string
+
+	for _, file := range files {
+		current, err := readExisting(dest, file.Path)
+		if err != nil {
+			return nil, err
+		}
+		base, err := readExisting(stateDir, file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		result := MergeResult{Path: file.Path, Content: file.Content}
+		switch {
+		case current == nil:
+			result.Status = MergeCreated
+		case bytes.Equal(current, file.Content):
+			result.Status = MergeUnchanged
+		case base != nil && bytes.Equal(current, base):
+			result.Status = MergeUpdated
+		default:
+			result.Content, result.Conflicts = ThreeWayMerge(base, current, file.Content)
+			result.Status = MergeMerged
+			if result.Conflicts > 0 {
+				result.Status = MergeConflict
+				conflicted = append(conflicted, file.Path)
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	if len(conflicted) > 0 && cfg.failOnConflict {
+		return results, &MergeConflictError{Paths: conflicted}
+	}
+
+	merged := make([]GeneratedFile, 0, len(files))
+	for i, file := range files {
+		if results[i].Status == MergeUnchanged {
+			continue
+		}
+		file.Content = results[i].Content
+		merged = append(merged, file)
+	}
+	if err := WriteFiles(dest, merged); err != nil {
+		return results, err
+	}
+
+	if err := WriteFiles(stateDir, files); err != nil {
+		return results, fmt.Errorf("failed to update merge state: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,82 @@
+package template_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	base := "package a\n\nfunc A() {}\n\nfunc B() {}\n"
+	current := "package a\n\n// A is documented by hand.\nfunc A() {}\n\nfunc B() {}\n"
+	generated := "package a\n\nfunc A() {}\n\nfunc B() {}\n\nfunc C() {}\n"
+
+	merged, conflicts := template.ThreeWayMerge([]byte(base), []byte(current), []byte(generated))
+	require.Zero(t, conflicts)
+	require.Equal(t, "package a\n\n// A is documented by hand.\nfunc A() {}\n\nfunc B() {}\n\nfunc C() {}\n", string(merged))
+}
+
+func TestThreeWayMerge_Conflict(t *testing.T) {
+	base := "a\nb\nc\n"
+	current := "a\nmine\nc\n"
+	generated := "a\ntheirs\nc\n"
+
+	merged, conflicts := template.ThreeWayMerge([]byte(base), []byte(current), []byte(generated))
+	require.Equal(t, 1, conflicts)
+	require.Equal(t, "a\n<<<<<<< current\nmine\n=======\ntheirs\n>>>>>>> generated\nc\n", string(merged))
+}
+
+func TestMergeFiles(t *testing.T) {
+	dest := writeTemplates(t, map[string]string{
+		"edited.txt":   "one\nhand edit\ntwo\nthree\n",
+		"pristine.txt": "v1\n",
+		"conflict.txt": "mine\n",
+	})
+	state := writeTemplates(t, map[string]string{
+		"edited.txt":   "one\ntwo\nthree\n",
+		"pristine.txt": "v1\n",
+		"conflict.txt": "base\n",
+	})
+
+	files := []template.GeneratedFile{
+		{Path: "edited.txt", Content: []byte("one\ntwo\nTHREE\n")},
+		{Path: "pristine.txt", Content: []byte("v2\n")},
+		{Path: "conflict.txt", Content: []byte("theirs\n")},
+		{Path: "new.txt", Content: []byte("new\n")},
+	}
+
+	_, err := template.MergeFiles(dest, state, files, template.WithMergeFailOnConflict(true))
+	var conflict *template.MergeConflictError
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, []string{"conflict.txt"}, conflict.Paths)
+	_, err = os.Stat(filepath.Join(dest, "new.txt"))
+	require.True(t, os.IsNotExist(err), "nothing is written on conflict")
+
+	results, err := template.MergeFiles(dest, state, files)
+	require.NoError(t, err)
+
+	statuses := make(map[string]template.MergeStatus)
+	for _, r := range results {
+		statuses[r.Path] = r.Status
+	}
+	require.Equal(t, map[string]template.MergeStatus{
+		"edited.txt":   template.MergeMerged,
+		"pristine.txt": template.MergeUpdated,
+		"conflict.txt": template.MergeConflict,
+		"new.txt":      template.MergeCreated,
+	}, statuses)
+
+	read := func(dir, name string) string {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+	require.Equal(t, "one\nhand edit\ntwo\nTHREE\n", read(dest, "edited.txt"))
+	require.Equal(t, "v2\n", read(dest, "pristine.txt"))
+	require.Equal(t, "<<<<<<< current\nmine\n=======\ntheirs\n>>>>>>> generated\n", read(dest, "conflict.txt"))
+	require.Equal(t, "one\ntwo\nTHREE\n", read(state, "edited.txt"), "state holds the generated output")
+}
@@ -0,0 +1,126 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// PanicError is returned when a hook, a template function or an interceptor
+// panics during a render. At most one of Hook and Function is set. Stack is
+// the stack trace of the panicking goroutine.
+type PanicError struct {
+	// Hook is the name of the panicking hook, or for unnamed hooks its kind
+	// and position, e.g. "pre hook #2".
+	Hook string
+	// Function is the name the panicking template function or filter was
+	// registered under.
+	Function string
+	// TemplateName is empty for RenderString.
+	TemplateName string
+	Value        any
+	Stack        []byte
+}
+
+func (e *PanicError) Error() string {
+	subject := "render"
+	switch {
+	case e.Hook != "":
+		subject = fmt.Sprintf("hook %q", e.Hook)
+	case e.Function != "":
+		subject = fmt.Sprintf("template function %q", e.Function)
+	}
+
+	if e.TemplateName == "" {
+		return fmt.Sprintf("%s panicked: %v", subject, e.Value)
+	}
+	return fmt.Sprintf("%s panicked rendering %q: %v", subject, e.TemplateName, e.Value)
+}
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// funcPanic carries a panic raised by a template function through pongo2 up
+// to the recover around template execution, which has no other way to know
+// the function name.
+type funcPanic struct {
+	name  string
+	value any
+	stack []byte
+}
+
+func newPanicError(v any, hook, templateName string) *PanicError {
+	if fp, ok := v.(*funcPanic); ok {
+		return &PanicError{Function: fp.name, TemplateName: templateName, Value: fp.value, Stack: fp.stack}
+	}
+	return &PanicError{Hook: hook, TemplateName: templateName, Value: v, Stack: debug.Stack()}
+}
+
+// guard runs fn, turning a panic into a *PanicError naming hook.
+func guard(hook, templateName string, fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = newPanicError(v, hook, templateName)
+		}
+	}()
+	return fn()
+}
+
+// executeTemplate executes tmpl, turning a panic, typically raised by a
+// template function, into a *PanicError.
+func executeTemplate(tmpl *pongo2.Template, templateName string, ctx pongo2.Context, w io.Writer) error {
+	return guard("", templateName, func() error {
+		return tmpl.ExecuteWriter(ctx, w)
+	})
+}
+
+// hookLabel names a hook in panic errors.
+func hookLabel(name string, kind HookKind, index int) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s hook #%d", kind, index+1)
+}
+
+// tagPanic is deferred by template function wrappers to record the function
+// name of a panic before it unwinds through pongo2.
+func tagPanic(name string) {
+	if v := recover(); v != nil {
+		if _, ok := v.(*funcPanic); !ok {
+			v = &funcPanic{name: name, value: v, stack: debug.Stack()}
+		}
+		panic(v)
+	}
+}
+
+// recoverableFunc wraps a template function so panics it raises are reported
+// under name.
+func recoverableFunc(name string, fn any) any {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fn
+	}
+
+	t := v.Type()
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		defer tagPanic(name)
+		if t.IsVariadic() {
+			return v.CallSlice(args)
+		}
+		return v.Call(args)
+	}).Interface()
+}
+
+// recoverableFilter wraps a filter so panics it raises are reported under name.
+func recoverableFilter(name string, filter pongo2.FilterFunction) pongo2.FilterFunction {
+	return func(in, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+		defer tagPanic(name)
+		return filter(in, param)
+	}
+}
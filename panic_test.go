@@ -0,0 +1,155 @@
+package template_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_RecoversHookPanics(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.tpl": {Data: []byte("page")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	require.NoError(t, renderer.RegisterNamedPostHook("explode", func(ctx *template.HookContext) (string, error) {
+		var m map[string]int
+		m["x"] = 1
+		return ctx.Output, nil
+	}))
+
+	_, err = renderer.RenderTemplate("page", nil)
+	require.Error(t, err)
+
+	var panicErr *template.PanicError
+	require.True(t, errors.As(err, &panicErr))
+	require.Equal(t, "explode", panicErr.Hook)
+	require.Empty(t, panicErr.Function)
+	require.Equal(t, "page", panicErr.TemplateName)
+	require.Contains(t, string(panicErr.Stack), "panic_test.go")
+	require.Contains(t, err.Error(), `hook "explode" panicked rendering "page"`)
+
+	var renderErr *template.RenderError
+	require.True(t, errors.As(err, &renderErr))
+	require.Equal(t, template.PhasePostHook, renderErr.Phase)
+
+	var runtimeErr interface{ RuntimeError() }
+	require.True(t, errors.As(err, &runtimeErr), "panic value error is unwrapped")
+}
+
+func TestEngine_RecoversUnnamedHookPanics(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{}))
+	require.NoError(t, err)
+
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error { return nil })
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error { panic("bad data") })
+
+	_, err = renderer.RenderString("text", nil)
+	require.EqualError(t, err, `pre-hook failed: hook "pre hook #2" panicked: bad data`)
+}
+
+func TestEngine_RecoversTemplateFuncPanics(t *testing.T) {
+	fsys := fstest.MapFS{
+		"greet.tpl":  {Data: []byte("{{ shout(name) }}")},
+		"filter.tpl": {Data: []byte("{{ name|explode }}")},
+	}
+	renderer, err := template.NewRenderer(
+		template.WithFS(fsys),
+		template.WithTemplateFunc(map[string]any{
+			"shout": func(s string) string {
+				if s == "" {
+					panic("nothing to shout")
+				}
+				return strings.ToUpper(s)
+			},
+			"explode": func(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+				panic("filter failed")
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	output, err := renderer.RenderTemplate("greet", map[string]any{"name": "hi"})
+	require.NoError(t, err)
+	require.Equal(t, "HI", output)
+
+	_, err = renderer.RenderTemplate("greet", map[string]any{"name": ""})
+	require.Error(t, err)
+
+	var panicErr *template.PanicError
+	require.True(t, errors.As(err, &panicErr))
+	require.Equal(t, "shout", panicErr.Function)
+	require.Equal(t, "greet", panicErr.TemplateName)
+	require.Equal(t, "nothing to shout", panicErr.Value)
+	require.NotEmpty(t, panicErr.Stack)
+
+	var renderErr *template.RenderError
+	require.True(t, errors.As(err, &renderErr))
+	require.Equal(t, template.PhaseExecute, renderErr.Phase)
+
+	_, err = renderer.RenderTemplate("filter", map[string]any{"name": "x"})
+	require.True(t, errors.As(err, &panicErr))
+	require.Equal(t, "explode", panicErr.Function)
+}
+
+func TestEngine_RecoversLifecycleHookPanics(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{}))
+	require.NoError(t, err)
+
+	require.NoError(t, renderer.OnLoad(func(ctx *template.HookContext) {
+		panic("load")
+	}, template.WithHookName("loader")))
+
+	_, err = renderer.RenderString("text", nil)
+
+	var panicErr *template.PanicError
+	require.True(t, errors.As(err, &panicErr))
+	require.Equal(t, "loader", panicErr.Hook)
+
+	var renderErr *template.RenderError
+	require.True(t, errors.As(err, &renderErr))
+	require.Equal(t, template.PhaseLoad, renderErr.Phase)
+}
+
+func TestEngine_LoadHookPanicIsNotCached(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.tpl": {Data: []byte("hi")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	loads := 0
+	require.NoError(t, renderer.OnLoad(func(ctx *template.HookContext) {
+		loads++
+		if loads <= 2 {
+			panic("not ready")
+		}
+	}, template.WithHookName("validate")))
+
+	for range 2 {
+		out, err := renderer.RenderTemplate("page", nil)
+		require.Empty(t, out)
+
+		var panicErr *template.PanicError
+		require.ErrorAs(t, err, &panicErr)
+		require.Equal(t, "validate", panicErr.Hook)
+		require.Equal(t, "page", panicErr.TemplateName)
+
+		var renderErr *template.RenderError
+		require.ErrorAs(t, err, &renderErr)
+		require.Equal(t, template.PhaseLoad, renderErr.Phase)
+	}
+
+	for range 2 {
+		out, err := renderer.RenderTemplate("page", nil)
+		require.NoError(t, err)
+		require.Equal(t, "hi", out)
+	}
+	require.Equal(t, 3, loads, "a template is cached once its load hooks succeed")
+}
@@ -0,0 +1,249 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ChangeKind describes what writing a generated file would do to the output
+// directory.
+type ChangeKind string
+
+const (
+	ChangeCreated   ChangeKind = "created"
+	ChangeModified  ChangeKind = "modified"
+	ChangeUnchanged ChangeKind = "unchanged"
+	ChangeDeleted   ChangeKind = "deleted"
+	// ChangeKept marks an existing file the file's WritePolicy protects.
+	ChangeKept ChangeKind = "kept"
+)
+
+// FileChange is one entry of a generation plan.
+type FileChange struct {
+	// Path is the slash separated path relative to the output directory.
+	Path string
+	Kind ChangeKind
+	// Diff is a unified diff from the file on disk to the generated content.
+	// It is empty for unchanged files.
+	Diff string
+	// File is the generated file. It is zero for deleted files.
+	File GeneratedFile
+	// Reason explains why a kept file is not written.
+	Reason string
+}
+
+// PlanOption configures Plan.
+type PlanOption func(*planConfig)
+
+type planConfig struct {
+	previous    []string
+	context     int
+	withoutDiff bool
+	policy      WritePolicy
+}
+
+// WithPlanPrevious lists paths produced by a previous generation. Those that
+// are no longer generated but still exist on disk are reported as deleted.
+func WithPlanPrevious(paths ...string) PlanOption {
+	return func(c *planConfig) {
+		c.previous = append(c.previous, paths...)
+	}
+}
+
+// WithPlanContext sets the number of context lines in diffs, 3 by default.
+func WithPlanContext(lines int) PlanOption {
+	return func(c *planConfig) {
+		c.context = lines
+	}
+}
+
+// WithPlanWritePolicy sets the policy of files without one, mirroring
+// WithWritePolicy so a plan reports what WriteFilesAtomic would do.
+func WithPlanWritePolicy(policy WritePolicy) PlanOption {
+	return func(c *planConfig) {
+		c.policy = policy
+	}
+}
+
+// WithPlanDiff controls whether diffs are computed. It is enabled by default.
+func WithPlanDiff(enabled bool) PlanOption {
+	return func(c *planConfig) {
+		c.withoutDiff = !enabled
+	}
+}
+
+// Plan compares generated files with the contents of dest without writing
+// anything, making it the dry run counterpart of WriteFiles. Existing files
+// protected by the file's WritePolicy, or the one set by WithPlanWritePolicy,
+// are reported as kept. Changes are sorted by path.
+func Plan(dest string, files []GeneratedFile, opts ...PlanOption) ([]FileChange, error) {
+	cfg := planConfig{context: 3}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	changes := make([]FileChange, 0, len(files))
+	generated := make(map[string]bool, len(files))
+
+	for _, file := range files {
+		generated[file.Path] = true
+
+		existing, err := readExisting(dest, file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		change := FileChange{Path: file.Path, File: file}
+		switch {
+		case existing == nil:
+			change.Kind = ChangeCreated
+		case bytes.Equal(existing, file.Content):
+			change.Kind = ChangeUnchanged
+		default:
+			change.Kind = ChangeModified
+			policy := (&writeConfig{policy: cfg.policy}).policyFor(file)
+			if ok, reason := policy.allows(existing, file.Content); !ok {
+				change.Kind, change.Reason = ChangeKept, reason
+			}
+		}
+
+		if change.Kind != ChangeUnchanged && change.Kind != ChangeKept && !cfg.withoutDiff {
+			if change.Diff, err = unifiedDiff(file.Path, existing, file.Content, cfg.context); err != nil {
+				return nil, err
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	for _, p := range cfg.previous {
+		p = path.Clean(filepath.ToSlash(p))
+		if generated[p] {
+			continue
+		}
+		generated[p] = true
+
+		existing, err := readExisting(dest, p)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			continue
+		}
+
+		change := FileChange{Path: p, Kind: ChangeDeleted}
+		if !cfg.withoutDiff {
+			if change.Diff, err = unifiedDiff(p, existing, nil, cfg.context); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes, nil
+}
+
+// WritePlan prints a plan as a summary line per file followed by its diff.
+func WritePlan(w io.Writer, changes []FileChange) error {
+	counts := make(map[ChangeKind]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+		line := fmt.Sprintf("%-9s %s", change.Kind, change.Path)
+		if change.Reason != "" {
+			line += " (" + change.Reason + ")"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		if change.Diff != "" {
+			if _, err := io.WriteString(w, change.Diff); err != nil {
+				return err
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d created, %d modified, %d unchanged, %d deleted",
+		counts[ChangeCreated], counts[ChangeModified], counts[ChangeUnchanged], counts[ChangeDeleted])
+	if counts[ChangeKept] > 0 {
+		summary += fmt.Sprintf(", %d kept", counts[ChangeKept])
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+// readExisting returns the content of the file at p under dest, or nil when it
+// does not exist.
+func readExisting(dest, p string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(p)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+	if content == nil {
+		content = []byte{}
+	}
+	return content, nil
+}
+
+func unifiedDiff(p string, from, to []byte, context int) (string, error) {
+	if isBinary(from) || isBinary(to) {
+		return fmt.Sprintf("Binary files a/%s and b/%s differ\n", p, p), nil
+	}
+
+	fromFile, toFile := "a/"+p, "b/"+p
+	if from == nil {
+		fromFile = "/dev/null"
+	}
+	if to == nil {
+		toFile = "/dev/null"
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		B:        splitLines(to),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  context,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", p, err)
+	}
+	return diff, nil
+}
+
+// splitLines splits content keeping line endings, marking a missing final
+// newline the way diff(1) does.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	lines := difflib.SplitLines(string(content))
+	// SplitLines appends "\n" to the last line; drop it again.
+	last := len(lines) - 1
+	lines[last] = lines[last][:len(lines[last])-1]
+	if lines[last] == "" {
+		return lines[:last]
+	}
+	lines[last] += "\n\\ No newline at end of file\n"
+	return lines
+}
+
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
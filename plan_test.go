@@ -0,0 +1,112 @@
+package template_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	dest := writeTemplates(t, map[string]string{
+		"same.txt":     "unchanged\n",
+		"edit.txt":     "one\ntwo\nthree\n",
+		"old/gone.txt": "bye\n",
+	})
+
+	files := []template.GeneratedFile{
+		{Path: "same.txt", Content: []byte("unchanged\n")},
+		{Path: "edit.txt", Content: []byte("one\n2\nthree\n")},
+		{Path: "new/file.txt", Content: []byte("hello")},
+	}
+
+	changes, err := template.Plan(dest, files, template.WithPlanPrevious("edit.txt", "old/gone.txt", "never/existed.txt"))
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+
+	byPath := make(map[string]template.FileChange)
+	for _, c := range changes {
+		byPath[c.Path] = c
+	}
+
+	require.Equal(t, template.ChangeUnchanged, byPath["same.txt"].Kind)
+	require.Empty(t, byPath["same.txt"].Diff)
+
+	require.Equal(t, template.ChangeModified, byPath["edit.txt"].Kind)
+	require.Equal(t, "--- a/edit.txt\n+++ b/edit.txt\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n", byPath["edit.txt"].Diff)
+
+	require.Equal(t, template.ChangeCreated, byPath["new/file.txt"].Kind)
+	require.Equal(t, "--- /dev/null\n+++ b/new/file.txt\n@@ -0,0 +1 @@\n+hello\n\\ No newline at end of file\n", byPath["new/file.txt"].Diff)
+
+	require.Equal(t, template.ChangeDeleted, byPath["old/gone.txt"].Kind)
+	require.Equal(t, "--- a/old/gone.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n", byPath["old/gone.txt"].Diff)
+
+	// Nothing was written.
+	_, err = os.Stat(filepath.Join(dest, "new", "file.txt"))
+	require.True(t, os.IsNotExist(err))
+
+	var out bytes.Buffer
+	require.NoError(t, template.WritePlan(&out, changes))
+	require.Contains(t, out.String(), "modified  edit.txt\n--- a/edit.txt")
+	require.Contains(t, out.String(), "1 created, 1 modified, 1 unchanged, 1 deleted\n")
+}
+
+func TestPlan_BinaryAndWithoutDiff(t *testing.T) {
+	dest := writeTemplates(t, map[string]string{"logo.bin": "a\x00b"})
+
+	files := []template.GeneratedFile{{Path: "logo.bin", Content: []byte("a\x00c")}}
+
+	changes, err := template.Plan(dest, files)
+	require.NoError(t, err)
+	require.Equal(t, "Binary files a/logo.bin and b/logo.bin differ\n", changes[0].Diff)
+
+	changes, err = template.Plan(dest, files, template.WithPlanDiff(false))
+	require.NoError(t, err)
+	require.Equal(t, template.ChangeModified, changes[0].Kind)
+	require.Empty(t, changes[0].Diff)
+}
+
+func TestPlan_DefaultWritePolicyMatchesWrite(t *testing.T) {
+	dest := writeTemplates(t, map[string]string{
+		"edited.txt":  "edited by hand\n",
+		"forced.txt":  "edited by hand\n",
+		"created.txt": "",
+	})
+	require.NoError(t, os.Remove(filepath.Join(dest, "created.txt")))
+
+	files := []template.GeneratedFile{
+		{Path: "edited.txt", Content: []byte("generated\n")},
+		{Path: "forced.txt", Content: []byte("generated\n"), Policy: template.WriteAlways},
+		{Path: "created.txt", Content: []byte("generated\n")},
+	}
+
+	changes, err := template.Plan(dest, files, template.WithPlanWritePolicy(template.WriteCreateOnly))
+	require.NoError(t, err)
+
+	kinds := make(map[string]template.ChangeKind)
+	for _, c := range changes {
+		kinds[c.Path] = c.Kind
+	}
+	require.Equal(t, map[string]template.ChangeKind{
+		"edited.txt":  template.ChangeKept,
+		"forced.txt":  template.ChangeModified,
+		"created.txt": template.ChangeCreated,
+	}, kinds)
+
+	result, err := template.WriteFilesAtomic(dest, files, template.WithWritePolicy(template.WriteCreateOnly))
+	require.NoError(t, err)
+	require.Equal(t, []string{"edited.txt"}, result.Kept)
+
+	for _, c := range changes {
+		content, err := os.ReadFile(filepath.Join(dest, c.Path))
+		require.NoError(t, err)
+		if c.Kind == template.ChangeKept {
+			require.Equal(t, "edited by hand\n", string(content), c.Path)
+		} else {
+			require.Equal(t, "generated\n", string(content), c.Path)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package templatehooks
+
+import (
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/goliatone/go-template"
+)
+
+// CommentStyle describes how to write comments in a file type.
+type CommentStyle struct {
+	// Line starts a single line comment, e.g. "// " or "# ".
+	Line string
+	// LineSuffix closes a single line comment for languages without one,
+	// e.g. " -->" for HTML.
+	LineSuffix string
+	// Block renders multi line comments. When it is zero, blocks are written
+	// as consecutive line comments.
+	Block CommentBlockStyle
+}
+
+// Common comment styles used by the default registry.
+var (
+	SlashComments = CommentStyle{Line: "// ", Block: CommentBlockStyle{Start: "/*", LinePrefix: " * ", End: " */"}}
+	HashComments  = CommentStyle{Line: "# "}
+	DashComments  = CommentStyle{Line: "-- "}
+	SemiComments  = CommentStyle{Line: "; "}
+	CSSComments   = CommentStyle{Line: "/* ", LineSuffix: " */", Block: CommentBlockStyle{Start: "/*", LinePrefix: " * ", End: " */"}}
+	XMLComments   = CommentStyle{Line: "<!-- ", LineSuffix: " -->", Block: CommentBlockStyle{Start: "<!--", LinePrefix: "  ", End: "-->"}}
+)
+
+// LineComment renders content as a single line comment.
+func (s CommentStyle) LineComment(content string) string {
+	return s.Line + content + s.LineSuffix
+}
+
+// BuildBlock renders lines as a comment block, ending with a newline.
+func (s CommentStyle) BuildBlock(lines []string) string {
+	if s.Block != (CommentBlockStyle{}) {
+		return BuildCommentBlock(s.Block, lines)
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		if line == "" {
+			// Keep blank lines inside the comment without trailing spaces.
+			b.WriteString(strings.TrimRight(s.Line, " "))
+			b.WriteString(strings.TrimLeft(s.LineSuffix, " "))
+		} else {
+			b.WriteString(s.LineComment(line))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// CommentStyleRegistry maps file types to comment styles. Keys are extensions
+// such as ".py" or exact base names such as "Dockerfile". Files without a
+// known name are matched by the interpreter of their shebang line.
+type CommentStyleRegistry struct {
+	mu           sync.RWMutex
+	byName       map[string]CommentStyle
+	interpreters map[string]CommentStyle
+}
+
+// NewCommentStyleRegistry returns a registry preloaded with common languages
+// and configuration formats.
+func NewCommentStyleRegistry() *CommentStyleRegistry {
+	r := &CommentStyleRegistry{
+		byName:       make(map[string]CommentStyle),
+		interpreters: make(map[string]CommentStyle),
+	}
+
+	r.Register(SlashComments,
+		".go", ".c", ".h", ".cc", ".cpp", ".hpp", ".cs", ".java", ".kt", ".kts", ".scala",
+		".groovy", ".gradle", ".swift", ".rs", ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx",
+		".php", ".dart", ".proto", ".scss", ".less", ".m", ".zig", ".v", ".sv")
+	r.Register(HashComments,
+		".py", ".rb", ".sh", ".bash", ".zsh", ".fish", ".pl", ".pm", ".r", ".ps1", ".yaml",
+		".yml", ".toml", ".tf", ".tfvars", ".hcl", ".nix", ".cmake", ".mk", ".conf", ".cfg",
+		".properties", ".env", ".gitignore", ".dockerignore", ".editorconfig", ".gitattributes",
+		"Dockerfile", "Containerfile", "Makefile", "GNUmakefile", "CMakeLists.txt", "Gemfile",
+		"Rakefile", "Procfile", "Vagrantfile", "BUILD", "BUILD.bazel", "WORKSPACE")
+	r.Register(DashComments, ".sql", ".lua", ".hs", ".elm", ".ada")
+	r.Register(SemiComments, ".ini", ".lisp", ".el", ".clj", ".cljs", ".scm", ".asm")
+	r.Register(CSSComments, ".css")
+	r.Register(XMLComments, ".html", ".htm", ".xhtml", ".xml", ".svg", ".vue", ".svelte", ".md", ".xsd", ".xsl", ".plist")
+
+	r.RegisterInterpreter(HashComments, "sh", "bash", "zsh", "dash", "ksh", "fish", "python", "python3", "ruby", "perl", "Rscript", "pwsh")
+	r.RegisterInterpreter(SlashComments, "node", "deno", "bun")
+	r.RegisterInterpreter(DashComments, "lua")
+
+	return r
+}
+
+// Register associates style with extensions (".py") or base names
+// ("Dockerfile"), replacing previous associations.
+func (r *CommentStyleRegistry) Register(style CommentStyle, names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range names {
+		if strings.HasPrefix(name, ".") {
+			name = strings.ToLower(name)
+		}
+		r.byName[name] = style
+	}
+}
+
+// RegisterInterpreter associates style with shebang interpreters, e.g.
+// "python3" for "#!/usr/bin/env python3".
+func (r *CommentStyleRegistry) RegisterInterpreter(style CommentStyle, interpreters ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, interpreter := range interpreters {
+		r.interpreters[interpreter] = style
+	}
+}
+
+// Lookup returns the style of a file name, matching its base name first and
+// then its extension. A trailing unknown extension, such as a template
+// extension, is ignored, so "script.py.tpl" resolves like "script.py".
+func (r *CommentStyleRegistry) Lookup(name string) (CommentStyle, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	for i := 0; i < 2 && base != "" && base != "."; i++ {
+		if style, ok := r.byName[base]; ok {
+			return style, true
+		}
+		ext := path.Ext(base)
+		if style, ok := r.byName[strings.ToLower(ext)]; ok {
+			return style, true
+		}
+		if ext == "" || ext == base {
+			break
+		}
+		base = strings.TrimSuffix(base, ext)
+	}
+
+	return CommentStyle{}, false
+}
+
+// LookupShebang returns the style matching the interpreter of content's
+// shebang line.
+func (r *CommentStyleRegistry) LookupShebang(content string) (CommentStyle, bool) {
+	interpreter := shebangInterpreter(content)
+	if interpreter == "" {
+		return CommentStyle{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if style, ok := r.interpreters[interpreter]; ok {
+		return style, true
+	}
+	// python3.12 -> python
+	style, ok := r.interpreters[strings.TrimRight(interpreter, "0123456789.")]
+	return style, ok
+}
+
+// Resolve returns the style for a render: ctx.OutputPath, then
+// ctx.TemplateName, then the shebang of ctx.Output.
+func (r *CommentStyleRegistry) Resolve(ctx *template.HookContext) (CommentStyle, bool) {
+	if ctx.OutputPath != "" {
+		if style, ok := r.Lookup(ctx.OutputPath); ok {
+			return style, true
+		}
+	}
+	if ctx.TemplateName != "" {
+		if style, ok := r.Lookup(ctx.TemplateName); ok {
+			return style, true
+		}
+	}
+	return r.LookupShebang(ctx.Output)
+}
+
+// shebangInterpreter returns the interpreter named by a "#!" first line,
+// following "/usr/bin/env [-S] name".
+func shebangInterpreter(content string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return ""
+	}
+
+	line, _, _ := strings.Cut(content[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				return path.Base(field)
+			}
+		}
+		return ""
+	}
+	return interpreter
+}
+
+// CommentStyles returns the registry header hooks use to pick comment styles.
+// Callers may register additional file types on it.
+func (h *CommonHooks) CommentStyles() *CommentStyleRegistry {
+	h.stylesOnce.Do(func() {
+		if h.styles == nil {
+			h.styles = NewCommentStyleRegistry()
+		}
+	})
+	return h.styles
+}
+
+// lineStyle returns the style for line comment headers, using prefix when
+// set and the registry style for ctx otherwise.
+func (h *CommonHooks) lineStyle(ctx *template.HookContext, prefix string) CommentStyle {
+	if prefix != "" {
+		return CommentStyle{Line: prefix}
+	}
+	return h.commentStyle(ctx, defaultCommentStyle)
+}
+
+// commentStyle resolves the style for ctx, falling back to fallback for
+// unknown file types.
+func (h *CommonHooks) commentStyle(ctx *template.HookContext, fallback CommentStyle) CommentStyle {
+	if style, ok := h.CommentStyles().Resolve(ctx); ok {
+		return style
+	}
+	return fallback
+}
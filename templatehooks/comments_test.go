@@ -0,0 +1,147 @@
+package templatehooks_test
+
+import (
+	"testing"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/templatehooks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentStyleRegistry_Lookup(t *testing.T) {
+	registry := templatehooks.NewCommentStyleRegistry()
+
+	tests := []struct {
+		name string
+		want templatehooks.CommentStyle
+		ok   bool
+	}{
+		{"main.go", templatehooks.SlashComments, true},
+		{"app/script.py.tpl", templatehooks.HashComments, true},
+		{"config.YAML", templatehooks.HashComments, true},
+		{"schema.sql", templatehooks.DashComments, true},
+		{"index.html", templatehooks.XMLComments, true},
+		{"deploy/Dockerfile", templatehooks.HashComments, true},
+		{"Makefile.tpl", templatehooks.HashComments, true},
+		{"data.json", templatehooks.CommentStyle{}, false},
+		{"README", templatehooks.CommentStyle{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style, ok := registry.Lookup(tt.name)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.want, style)
+		})
+	}
+}
+
+func TestCommentStyleRegistry_LookupShebang(t *testing.T) {
+	registry := templatehooks.NewCommentStyleRegistry()
+
+	style, ok := registry.LookupShebang("#!/usr/bin/env python3.12\nprint(1)\n")
+	require.True(t, ok)
+	require.Equal(t, templatehooks.HashComments, style)
+
+	style, ok = registry.LookupShebang("#!/usr/bin/env -S node --no-warnings\n")
+	require.True(t, ok)
+	require.Equal(t, templatehooks.SlashComments, style)
+
+	_, ok = registry.LookupShebang("#!/opt/bin/unknown\n")
+	require.False(t, ok)
+
+	_, ok = registry.LookupShebang("no shebang\n")
+	require.False(t, ok)
+}
+
+func TestCommentStyle_BuildBlock(t *testing.T) {
+	require.Equal(t, "# Licensed\n#\n# Terms\n", templatehooks.HashComments.BuildBlock([]string{"Licensed", "", "Terms"}))
+	require.Equal(t, "<!--\n  Licensed\n-->\n", templatehooks.XMLComments.BuildBlock([]string{"Licensed"}))
+	require.Equal(t, "<!-- note -->", templatehooks.XMLComments.LineComment("note"))
+}
+
+func TestCommonHooks_HeaderHooksUseCommentStyles(t *testing.T) {
+	hooks := templatehooks.NewCommonHooks()
+	always := func(*template.HookContext) bool { return true }
+
+	tests := []struct {
+		name string
+		ctx  template.HookContext
+		hook template.PostHook
+		want string
+	}{
+		{
+			name: "copyright python",
+			ctx:  template.HookContext{TemplateName: "tool.py.tpl", Output: "print(1)\n"},
+			hook: hooks.AddCopyrightHook("Copyright ACME", templatehooks.WithCopyrightCondition(always)),
+			want: "# Copyright ACME\nprint(1)\n",
+		},
+		{
+			name: "copyright output path wins",
+			ctx:  template.HookContext{TemplateName: "query.tpl", OutputPath: "db/query.sql", Output: "SELECT 1;\n"},
+			hook: hooks.AddCopyrightHook("Copyright ACME", templatehooks.WithCopyrightCondition(always)),
+			want: "-- Copyright ACME\nSELECT 1;\n",
+		},
+		{
+			name: "copyright shebang",
+			ctx:  template.HookContext{TemplateName: "run", Output: "#!/bin/bash\necho hi\n"},
+			hook: hooks.AddCopyrightHook("Copyright ACME", templatehooks.WithCopyrightCondition(always)),
+			want: "#!/bin/bash\n# Copyright ACME\necho hi\n",
+		},
+		{
+			name: "explicit prefix wins",
+			ctx:  template.HookContext{TemplateName: "tool.py", Output: "x\n"},
+			hook: hooks.AddCopyrightHook("Copyright ACME", templatehooks.WithCopyrightCondition(always), templatehooks.WithCopyrightCommentPrefix("## ")),
+			want: "## Copyright ACME\nx\n",
+		},
+		{
+			name: "unknown falls back to slashes",
+			ctx:  template.HookContext{TemplateName: "thing.unknown", Output: "x\n"},
+			hook: hooks.AddCopyrightHook("Copyright ACME", templatehooks.WithCopyrightCondition(always)),
+			want: "// Copyright ACME\nx\n",
+		},
+		{
+			name: "license yaml",
+			ctx:  template.HookContext{TemplateName: "values.yaml", Output: "a: 1\n"},
+			hook: hooks.AddLicenseHook("MIT\n\nTerms", templatehooks.WithLicenseCondition(always)),
+			want: "# MIT\n#\n# Terms\n\na: 1\n",
+		},
+		{
+			name: "license html",
+			ctx:  template.HookContext{TemplateName: "page.html", Output: "<p></p>\n"},
+			hook: hooks.AddLicenseHook("MIT", templatehooks.WithLicenseCondition(always)),
+			want: "<!--\n  MIT\n-->\n\n<p></p>\n",
+		},
+		{
+			name: "generated warning html",
+			ctx:  template.HookContext{TemplateName: "page.html", Output: "<p></p>\n"},
+			hook: hooks.AddGeneratedWarningHook(templatehooks.WithGeneratedWarningCondition(always)),
+			want: "<!-- Code generated by go-template. DO NOT EDIT. -->\n<p></p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			got, err := tt.hook(&ctx)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommonHooks_CommentStylesExtendable(t *testing.T) {
+	hooks := templatehooks.NewCommonHooks()
+	hooks.CommentStyles().Register(templatehooks.CommentStyle{Line: "%% "}, ".erl")
+
+	ctx := &template.HookContext{TemplateName: "app.erl", Output: "-module(app).\n"}
+	got, err := hooks.AddCopyrightHook("Copyright ACME",
+		templatehooks.WithCopyrightCondition(func(*template.HookContext) bool { return true }),
+	)(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "%% Copyright ACME\n-module(app).\n", got)
+
+	shared := templatehooks.NewCommentStyleRegistry()
+	other := templatehooks.NewCommonHooks(templatehooks.WithCommentStyles(shared))
+	require.Same(t, shared, other.CommentStyles())
+}
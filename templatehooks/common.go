@@ -3,6 +3,7 @@ package templatehooks
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/goliatone/go-template"
@@ -11,11 +12,38 @@ import (
 ////////////////////////////////////////////////////////////////////////////////
 
 // CommonHooks provides a collection of commonly used hooks
-type CommonHooks struct{}
+type CommonHooks struct {
+	styles     *CommentStyleRegistry
+	stylesOnce sync.Once
+	clock      template.Clock
+}
+
+// CommonHooksOption configures NewCommonHooks.
+type CommonHooksOption func(*CommonHooks)
+
+// WithCommentStyles sets the registry header hooks use to pick comment
+// styles, allowing several CommonHooks to share one.
+func WithCommentStyles(registry *CommentStyleRegistry) CommonHooksOption {
+	return func(h *CommonHooks) {
+		h.styles = registry
+	}
+}
+
+// WithClock sets the clock hooks stamp times with. Without it hooks use
+// HookContext.Clock, and template.DefaultClock outside an engine.
+func WithClock(clock template.Clock) CommonHooksOption {
+	return func(h *CommonHooks) {
+		h.clock = clock
+	}
+}
 
 // NewCommonHooks creates a new instance of CommonHooks
-func NewCommonHooks() *CommonHooks {
-	return &CommonHooks{}
+func NewCommonHooks(opts ...CommonHooksOption) *CommonHooks {
+	h := &CommonHooks{}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // TimestampHookOption configures the behaviour of AddTimestampHook.
@@ -30,7 +58,8 @@ type TimestampHookConfig struct {
 	MessageFormat string
 }
 
-// WithTimestampCommentPrefix overrides the default line comment prefix.
+// WithTimestampCommentPrefix overrides the line comment prefix otherwise
+// picked from the comment style registry.
 func WithTimestampCommentPrefix(prefix string) TimestampHookOption {
 	return func(cfg *TimestampHookConfig) {
 		cfg.CommentPrefix = prefix
@@ -44,7 +73,9 @@ func WithTimestampFormat(layout string) TimestampHookOption {
 	}
 }
 
-// WithTimestampLocation changes the time zone/location used for timestamps.
+// WithTimestampLocation changes the time zone/location used for timestamps. By
+// default the location of the clock is kept: local time for the system clock
+// and UTC for SOURCE_DATE_EPOCH.
 func WithTimestampLocation(loc *time.Location) TimestampHookOption {
 	return func(cfg *TimestampHookConfig) {
 		cfg.Location = loc
@@ -67,13 +98,12 @@ func WithTimestampMessageFormat(message string) TimestampHookOption {
 }
 
 // AddTimestampHook returns a post hook that prepends a timestamp comment to ctx.Output.
+// An existing timestamp line with the same message is updated in place instead.
 // The hook never mutates ctx.Data or ctx.Metadata and simply returns the original
 // output when the configured condition evaluates to false.
 func (h *CommonHooks) AddTimestampHook(opts ...TimestampHookOption) template.PostHook {
 	cfg := TimestampHookConfig{
-		CommentPrefix: "// ",
-		Format:        "2006-01-02 15:04:05",
-		Location:      time.Local,
+		Format: "2006-01-02 15:04:05",
 		Condition: func(ctx *template.HookContext) bool {
 			return isGoFile(ctx.TemplateName, ctx.Output)
 		},
@@ -89,14 +119,18 @@ func (h *CommonHooks) AddTimestampHook(opts ...TimestampHookOption) template.Pos
 			return ctx.Output, nil
 		}
 
-		timestamp := time.Now()
+		timestamp := h.now(ctx)
 		if cfg.Location != nil {
 			timestamp = timestamp.In(cfg.Location)
 		}
 
+		style := h.lineStyle(ctx, cfg.CommentPrefix)
 		formatted := fmt.Sprintf(cfg.MessageFormat, timestamp.Format(cfg.Format))
-		header := BuildLineComment(cfg.CommentPrefix, formatted)
-		return header + "\n" + ctx.Output, nil
+
+		return insertHeader(ctx.Output, style, header{
+			lines:      []string{style.LineComment(formatted)},
+			equivalent: timestampPattern(style, cfg.MessageFormat),
+		}), nil
 	}
 }
 
@@ -109,7 +143,8 @@ type CopyrightHookConfig struct {
 	Condition     template.HookCondition
 }
 
-// WithCopyrightCommentPrefix overrides the default prefix ("// ").
+// WithCopyrightCommentPrefix overrides the prefix otherwise picked from the
+// comment style registry.
 func WithCopyrightCommentPrefix(prefix string) CopyrightHookOption {
 	return func(cfg *CopyrightHookConfig) {
 		cfg.CommentPrefix = prefix
@@ -124,11 +159,11 @@ func WithCopyrightCondition(condition template.HookCondition) CopyrightHookOptio
 }
 
 // AddCopyrightHook returns a post hook that prepends a single-line copyright
-// comment to ctx.Output. When the supplied copyright string is empty or the
-// condition blocks execution, the original output is returned unchanged.
+// comment to ctx.Output, replacing an existing one that differs only in
+// years. When the supplied copyright string is empty or the condition blocks
+// execution, the original output is returned unchanged.
 func (h *CommonHooks) AddCopyrightHook(copyright string, opts ...CopyrightHookOption) template.PostHook {
 	cfg := CopyrightHookConfig{
-		CommentPrefix: "// ",
 		Condition: func(ctx *template.HookContext) bool {
 			return isCodeFile(ctx.TemplateName, ctx.Output)
 		},
@@ -147,8 +182,10 @@ func (h *CommonHooks) AddCopyrightHook(copyright string, opts ...CopyrightHookOp
 			return ctx.Output, nil
 		}
 
-		header := BuildLineComment(cfg.CommentPrefix, copyright)
-		return header + "\n" + ctx.Output, nil
+		style := h.lineStyle(ctx, cfg.CommentPrefix)
+		return insertHeader(ctx.Output, style, header{
+			lines: []string{style.LineComment(copyright)},
+		}), nil
 	}
 }
 
@@ -194,7 +231,8 @@ type LicenseHookConfig struct {
 	Condition template.HookCondition
 }
 
-// WithLicenseCommentStyle overrides the block comment style used for the license header.
+// WithLicenseCommentStyle overrides the block comment style otherwise picked
+// from the comment style registry.
 func WithLicenseCommentStyle(style CommentBlockStyle) LicenseHookOption {
 	return func(cfg *LicenseHookConfig) {
 		cfg.Style = style
@@ -208,18 +246,15 @@ func WithLicenseCondition(condition template.HookCondition) LicenseHookOption {
 	}
 }
 
-var defaultLicenseStyle = CommentBlockStyle{
-	Start:      "/*",
-	LinePrefix: " * ",
-	End:        " */",
-}
+// defaultCommentStyle applies to file types the registry does not know.
+var defaultCommentStyle = SlashComments
 
 // AddLicenseHook returns a post hook that emits a formatted comment block before
-// ctx.Output. The hook leaves ctx.Data untouched and falls back to the incoming
-// output when the license text is empty or the condition declines execution.
+// ctx.Output, unless the output already starts with the same block. The hook
+// leaves ctx.Data untouched and falls back to the incoming output when the
+// license text is empty or the condition declines execution.
 func (h *CommonHooks) AddLicenseHook(license string, opts ...LicenseHookOption) template.PostHook {
 	cfg := LicenseHookConfig{
-		Style: defaultLicenseStyle,
 		Condition: func(ctx *template.HookContext) bool {
 			return isCodeFile(ctx.TemplateName, ctx.Output)
 		},
@@ -238,9 +273,16 @@ func (h *CommonHooks) AddLicenseHook(license string, opts ...LicenseHookOption)
 			return ctx.Output, nil
 		}
 
-		lines := strings.Split(license, "\n")
-		header := BuildCommentBlock(cfg.Style, lines) + "\n"
-		return header + ctx.Output, nil
+		style := CommentStyle{Block: cfg.Style}
+		if cfg.Style == (CommentBlockStyle{}) {
+			style = h.commentStyle(ctx, defaultCommentStyle)
+		}
+
+		block := style.BuildBlock(strings.Split(license, "\n"))
+		return insertHeader(ctx.Output, style, header{
+			lines:     strings.Split(strings.TrimSuffix(block, "\n"), "\n"),
+			separator: "\n",
+		}), nil
 	}
 }
 
@@ -254,7 +296,8 @@ type GeneratedWarningHookConfig struct {
 	Condition     template.HookCondition
 }
 
-// WithGeneratedWarningCommentPrefix overrides the default prefix ("// ").
+// WithGeneratedWarningCommentPrefix overrides the prefix otherwise picked from
+// the comment style registry.
 func WithGeneratedWarningCommentPrefix(prefix string) GeneratedWarningHookOption {
 	return func(cfg *GeneratedWarningHookConfig) {
 		cfg.CommentPrefix = prefix
@@ -276,12 +319,12 @@ func WithGeneratedWarningCondition(condition template.HookCondition) GeneratedWa
 }
 
 // AddGeneratedWarningHook returns a post hook that prefixes ctx.Output with a
-// configurable generated-code warning. When the condition is not met it simply
-// returns ctx.Output, leaving ctx.Data and ctx.Metadata unchanged.
+// configurable generated-code warning, unless the output already carries it.
+// When the condition is not met it simply returns ctx.Output, leaving ctx.Data
+// and ctx.Metadata unchanged.
 func (h *CommonHooks) AddGeneratedWarningHook(opts ...GeneratedWarningHookOption) template.PostHook {
 	cfg := GeneratedWarningHookConfig{
-		CommentPrefix: "// ",
-		Message:       "Code generated by go-template. DO NOT EDIT.",
+		Message: "Code generated by go-template. DO NOT EDIT.",
 		Condition: func(ctx *template.HookContext) bool {
 			return isGoFile(ctx.TemplateName, ctx.Output)
 		},
@@ -296,8 +339,10 @@ func (h *CommonHooks) AddGeneratedWarningHook(opts ...GeneratedWarningHookOption
 			return ctx.Output, nil
 		}
 
-		header := BuildLineComment(cfg.CommentPrefix, cfg.Message)
-		return header + "\n" + ctx.Output, nil
+		style := h.lineStyle(ctx, cfg.CommentPrefix)
+		return insertHeader(ctx.Output, style, header{
+			lines: []string{style.LineComment(cfg.Message)},
+		}), nil
 	}
 }
 
@@ -319,7 +364,7 @@ func (h *CommonHooks) RemoveTrailingWhitespaceHook() template.PostHook {
 // alter ctx.Data or the template content.
 func (h *CommonHooks) AddMetadataHook() template.PreHook {
 	return func(ctx *template.HookContext) error {
-		ctx.Metadata["processed_at"] = time.Now()
+		ctx.Metadata["processed_at"] = h.now(ctx)
 		ctx.Metadata["template_name"] = ctx.TemplateName
 		ctx.Metadata["template_content"] = ctx.Template
 		return nil
@@ -377,6 +422,19 @@ func (h *CommonHooks) SetDefaultsHook(defaults map[string]any) template.PreHook
 
 // Helper functions
 
+// now returns the current time from the hooks clock, the engine clock or the
+// default clock, in that order.
+func (h *CommonHooks) now(ctx *template.HookContext) time.Time {
+	switch {
+	case h.clock != nil:
+		return h.clock.Now()
+	case ctx.Clock != nil:
+		return ctx.Clock.Now()
+	default:
+		return template.DefaultClock().Now()
+	}
+}
+
 // isGoFile checks if the output is for a Go file
 func isGoFile(templateName, output string) bool {
 	return strings.HasSuffix(templateName, ".go") ||
@@ -305,3 +305,34 @@ func TestCommonHooks_RenderString_RemoveWhitespace(t *testing.T) {
 
 	require.Equal(t, "Hello Alice!\nWelcome to TestApp.", result)
 }
+
+func TestCommonHooks_Clock(t *testing.T) {
+	fixed := time.Date(2024, 3, 9, 18, 30, 0, 0, time.UTC)
+
+	// The engine clock reaches hooks through the context.
+	renderer, err := template.NewRenderer(template.WithBaseDir("../testdata"), template.WithClock(template.FixedClock(fixed)))
+	require.NoError(t, err)
+
+	hooks := templatehooks.NewCommonHooks()
+	renderer.RegisterPostHook(hooks.AddTimestampHook())
+
+	result, err := renderer.RenderString("package main\n", nil)
+	require.NoError(t, err)
+	require.Equal(t, "// Generated on 2024-03-09 18:30:00\npackage main\n", result)
+
+	// An explicit hooks clock wins over the engine clock.
+	other := templatehooks.NewCommonHooks(templatehooks.WithClock(template.FixedClock(fixed.AddDate(1, 0, 0))))
+	ctx := &template.HookContext{Metadata: map[string]any{}, Clock: template.FixedClock(fixed)}
+	require.NoError(t, other.AddMetadataHook()(ctx))
+	require.Equal(t, fixed.AddDate(1, 0, 0), ctx.Metadata["processed_at"])
+}
+
+func TestCommonHooks_SourceDateEpoch(t *testing.T) {
+	t.Setenv(template.SourceDateEpochEnv, "1700000000")
+
+	hooks := templatehooks.NewCommonHooks()
+	ctx := &template.HookContext{TemplateName: "main.go", Output: "package main\n"}
+	out, err := hooks.AddTimestampHook()(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "// Generated on 2023-11-14 22:13:20\npackage main\n", out)
+}
@@ -0,0 +1,320 @@
+package templatehooks
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goliatone/go-template"
+)
+
+// ArrayMergeStrategy controls how default arrays combine with arrays already
+// present in the render data.
+type ArrayMergeStrategy int
+
+const (
+	// ArrayMergeReplace keeps the data array as is; defaults only fill missing arrays.
+	ArrayMergeReplace ArrayMergeStrategy = iota
+	// ArrayMergeAppend appends the default items after the data items.
+	ArrayMergeAppend
+	// ArrayMergeUnique appends the default items that are not already present.
+	ArrayMergeUnique
+)
+
+// DeepDefaultsHookOption configures DeepDefaultsHook behaviour.
+type DeepDefaultsHookOption func(*DeepDefaultsHookConfig)
+
+// DeepDefaultsHookConfig captures settings for DeepDefaultsHook.
+type DeepDefaultsHookConfig struct {
+	ArrayStrategy ArrayMergeStrategy
+	Coerce        bool
+	Condition     template.HookCondition
+}
+
+// WithDefaultsArrayStrategy selects how arrays are merged (ArrayMergeReplace by default).
+func WithDefaultsArrayStrategy(strategy ArrayMergeStrategy) DeepDefaultsHookOption {
+	return func(cfg *DeepDefaultsHookConfig) {
+		cfg.ArrayStrategy = strategy
+	}
+}
+
+// WithDefaultsCoercion converts existing values to the type of their default,
+// e.g. a "5432" string becomes a number when the default is a number.
+func WithDefaultsCoercion(enabled bool) DeepDefaultsHookOption {
+	return func(cfg *DeepDefaultsHookConfig) {
+		cfg.Coerce = enabled
+	}
+}
+
+// WithDefaultsCondition sets a predicate governing when the hook executes.
+func WithDefaultsCondition(condition template.HookCondition) DeepDefaultsHookOption {
+	return func(cfg *DeepDefaultsHookConfig) {
+		cfg.Condition = condition
+	}
+}
+
+// DeepDefaultsHook returns a pre hook that deep-merges defaults into ctx.Data.
+// Keys may be plain names holding nested maps, dotted paths ("config.db.port")
+// or JSON pointers ("/config/db/port"). Values already present in the data win;
+// nested maps are merged recursively and arrays follow the configured strategy.
+// ctx.Data is converted with template.ConvertToContext and replaced by the
+// merged map so later hooks observe the result.
+func (h *CommonHooks) DeepDefaultsHook(defaults map[string]any, opts ...DeepDefaultsHookOption) template.PreHook {
+	cfg := DeepDefaultsHookConfig{
+		ArrayStrategy: ArrayMergeReplace,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	expanded, expandErr := ExpandPaths(defaults)
+
+	return func(ctx *template.HookContext) error {
+		if cfg.Condition != nil && !cfg.Condition(ctx) {
+			return nil
+		}
+
+		if expandErr != nil {
+			return expandErr
+		}
+
+		data, err := template.ConvertToContext(ctx.Data)
+		if err != nil {
+			return fmt.Errorf("unable to coerce data to map: %w", err)
+		}
+
+		merged := map[string]any(data)
+		if err := MergeDefaults(merged, expanded, cfg.ArrayStrategy, cfg.Coerce); err != nil {
+			return err
+		}
+
+		ctx.Data = merged
+		return nil
+	}
+}
+
+// ExpandPaths turns dotted or JSON pointer keys into nested maps and
+// normalises values through JSON so they match the shape of converted data.
+// Keys are expanded from the shortest path to the longest, so when keys
+// overlap the more specific path wins: {"config": {"db": {"port": 1}},
+// "config.db.port": 2} sets port to 2.
+func ExpandPaths(values map[string]any) (map[string]any, error) {
+	type entry struct {
+		key  string
+		path []string
+	}
+
+	entries := make([]entry, 0, len(values))
+	for key := range values {
+		path := splitPath(key)
+		if len(path) == 0 {
+			return nil, fmt.Errorf("invalid default path %q", key)
+		}
+		entries = append(entries, entry{key: key, path: path})
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		if n := cmp.Compare(len(a.path), len(b.path)); n != 0 {
+			return n
+		}
+		return strings.Compare(a.key, b.key)
+	})
+
+	out := make(map[string]any, len(values))
+	for _, e := range entries {
+		key, path := e.key, e.path
+		normalised, err := normaliseJSON(values[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid default for %q: %w", key, err)
+		}
+
+		node := out
+		for i, segment := range path[:len(path)-1] {
+			next, ok := node[segment]
+			if !ok {
+				child := make(map[string]any)
+				node[segment] = child
+				node = child
+				continue
+			}
+			child, ok := next.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("default path %q conflicts with value at %q", key, strings.Join(path[:i+1], "."))
+			}
+			node = child
+		}
+
+		leaf := path[len(path)-1]
+		if existing, ok := node[leaf].(map[string]any); ok {
+			m, ok := normalised.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("default path %q conflicts with nested defaults", key)
+			}
+			if err := MergeDefaults(m, existing, ArrayMergeReplace, false); err != nil {
+				return nil, err
+			}
+		}
+		node[leaf] = normalised
+	}
+	return out, nil
+}
+
+func splitPath(key string) []string {
+	if strings.HasPrefix(key, "/") {
+		parts := strings.Split(key[1:], "/")
+		for i, p := range parts {
+			parts[i] = unescapePointer(p)
+		}
+		return parts
+	}
+	if key == "" {
+		return nil
+	}
+	return strings.Split(key, ".")
+}
+
+// MergeDefaults fills dst with values from defaults that dst does not define,
+// recursing into nested maps. Both maps are expected to hold JSON shaped values
+// (map[string]any, []any, float64, string, bool, nil).
+func MergeDefaults(dst, defaults map[string]any, strategy ArrayMergeStrategy, coerce bool) error {
+	return mergeDefaults(dst, defaults, strategy, coerce, "")
+}
+
+func mergeDefaults(dst, defaults map[string]any, strategy ArrayMergeStrategy, coerce bool, prefix string) error {
+	for key, def := range defaults {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		existing, ok := dst[key]
+		if !ok {
+			dst[key] = deepCopy(def)
+			continue
+		}
+
+		switch d := def.(type) {
+		case map[string]any:
+			if e, ok := existing.(map[string]any); ok {
+				if err := mergeDefaults(e, d, strategy, coerce, path); err != nil {
+					return err
+				}
+				continue
+			}
+		case []any:
+			if e, ok := existing.([]any); ok {
+				dst[key] = mergeArrays(e, d, strategy)
+				continue
+			}
+		}
+
+		if coerce {
+			value, err := coerceTo(existing, def)
+			if err != nil {
+				return fmt.Errorf("unable to coerce %s: %w", path, err)
+			}
+			dst[key] = value
+		}
+	}
+	return nil
+}
+
+func mergeArrays(existing, defaults []any, strategy ArrayMergeStrategy) []any {
+	switch strategy {
+	case ArrayMergeAppend:
+		out := append([]any{}, existing...)
+		for _, item := range defaults {
+			out = append(out, deepCopy(item))
+		}
+		return out
+	case ArrayMergeUnique:
+		out := append([]any{}, existing...)
+		for _, item := range defaults {
+			if !containsValue(out, item) {
+				out = append(out, deepCopy(item))
+			}
+		}
+		return out
+	default:
+		return existing
+	}
+}
+
+func containsValue(items []any, value any) bool {
+	for _, item := range items {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// coerceTo converts value to the JSON type of target.
+func coerceTo(value, target any) (any, error) {
+	if target == nil || value == nil || jsonType(value) == jsonType(target) {
+		return value, nil
+	}
+
+	switch target.(type) {
+	case float64:
+		switch v := value.(type) {
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+			return f, nil
+		case bool:
+			if v {
+				return float64(1), nil
+			}
+			return float64(0), nil
+		}
+	case bool:
+		switch v := value.(type) {
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a boolean", v)
+			}
+			return b, nil
+		case float64:
+			return v != 0, nil
+		}
+	case string:
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	case []any:
+		if _, ok := value.(map[string]any); !ok {
+			return []any{value}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to %s", jsonType(value), jsonType(target))
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = deepCopy(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,176 @@
+package templatehooks_test
+
+import (
+	"testing"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/templatehooks"
+	"github.com/stretchr/testify/require"
+)
+
+func runDeepDefaults(t *testing.T, data any, defaults map[string]any, opts ...templatehooks.DeepDefaultsHookOption) (map[string]any, error) {
+	t.Helper()
+
+	hooks := templatehooks.NewCommonHooks()
+	ctx := &template.HookContext{Data: data, Metadata: map[string]any{}}
+	if err := hooks.DeepDefaultsHook(defaults, opts...)(ctx); err != nil {
+		return nil, err
+	}
+
+	out, ok := ctx.Data.(map[string]any)
+	require.True(t, ok)
+	return out, nil
+}
+
+func TestCommonHooks_DeepDefaultsHook_NestedPaths(t *testing.T) {
+	data, err := runDeepDefaults(t,
+		map[string]any{
+			"config": map[string]any{
+				"db": map[string]any{"host": "db.internal"},
+			},
+		},
+		map[string]any{
+			"config.db.port":  5432,
+			"/config/db/host": "localhost",
+			"config": map[string]any{
+				"cache": map[string]any{"ttl": "5m"},
+			},
+			"name": "service",
+		},
+	)
+	require.NoError(t, err)
+
+	db := data["config"].(map[string]any)["db"].(map[string]any)
+	require.Equal(t, "db.internal", db["host"])
+	require.Equal(t, float64(5432), db["port"])
+
+	cache := data["config"].(map[string]any)["cache"].(map[string]any)
+	require.Equal(t, "5m", cache["ttl"])
+	require.Equal(t, "service", data["name"])
+}
+
+func TestCommonHooks_DeepDefaultsHook_StructData(t *testing.T) {
+	type db struct {
+		Host string `json:"host"`
+	}
+	type config struct {
+		DB db `json:"db"`
+	}
+
+	data, err := runDeepDefaults(t,
+		struct {
+			Config config `json:"config"`
+		}{Config: config{DB: db{Host: "remote"}}},
+		map[string]any{"config.db.port": 3306},
+	)
+	require.NoError(t, err)
+
+	dbMap := data["config"].(map[string]any)["db"].(map[string]any)
+	require.Equal(t, "remote", dbMap["host"])
+	require.Equal(t, float64(3306), dbMap["port"])
+}
+
+func TestCommonHooks_DeepDefaultsHook_ArrayStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy templatehooks.ArrayMergeStrategy
+		expected []any
+	}{
+		{name: "replace", strategy: templatehooks.ArrayMergeReplace, expected: []any{"auth", "api"}},
+		{name: "append", strategy: templatehooks.ArrayMergeAppend, expected: []any{"auth", "api", "api", "metrics"}},
+		{name: "unique", strategy: templatehooks.ArrayMergeUnique, expected: []any{"auth", "api", "metrics"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := runDeepDefaults(t,
+				map[string]any{"features": []string{"auth", "api"}},
+				map[string]any{"features": []string{"api", "metrics"}},
+				templatehooks.WithDefaultsArrayStrategy(tt.strategy),
+			)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, data["features"])
+		})
+	}
+}
+
+func TestCommonHooks_DeepDefaultsHook_Coercion(t *testing.T) {
+	data, err := runDeepDefaults(t,
+		map[string]any{
+			"config":  map[string]any{"port": "8080", "debug": "true", "tags": "web"},
+			"version": 2,
+		},
+		map[string]any{
+			"config.port":  80,
+			"config.debug": false,
+			"config.tags":  []string{},
+			"version":      "1",
+		},
+		templatehooks.WithDefaultsCoercion(true),
+	)
+	require.NoError(t, err)
+
+	config := data["config"].(map[string]any)
+	require.Equal(t, float64(8080), config["port"])
+	require.Equal(t, true, config["debug"])
+	require.Equal(t, []any{"web"}, config["tags"])
+	require.Equal(t, "2", data["version"])
+
+	_, err = runDeepDefaults(t,
+		map[string]any{"config": map[string]any{"port": "http"}},
+		map[string]any{"config.port": 80},
+		templatehooks.WithDefaultsCoercion(true),
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "config.port")
+}
+
+func TestCommonHooks_DeepDefaultsHook_WithoutCoercionKeepsValues(t *testing.T) {
+	data, err := runDeepDefaults(t,
+		map[string]any{"port": "8080"},
+		map[string]any{"port": 80},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "8080", data["port"])
+}
+
+func TestCommonHooks_DeepDefaultsHook_ConflictingPaths(t *testing.T) {
+	_, err := runDeepDefaults(t, nil, map[string]any{
+		"config":         "flat",
+		"config.db.port": 1,
+	})
+	require.Error(t, err)
+}
+
+func TestCommonHooks_DeepDefaultsHook_OverlappingPaths(t *testing.T) {
+	defaults := map[string]any{
+		"config":         map[string]any{"db": map[string]any{"port": 1, "host": "localhost"}},
+		"config.db.port": 2,
+		"/config/db":     map[string]any{"user": "admin"},
+	}
+
+	for range 50 {
+		data, err := runDeepDefaults(t, nil, defaults)
+		require.NoError(t, err)
+
+		db := data["config"].(map[string]any)["db"].(map[string]any)
+		require.Equal(t, map[string]any{"port": float64(2), "host": "localhost", "user": "admin"}, db)
+	}
+}
+
+func TestCommonHooks_DeepDefaultsHook_Render(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithBaseDir("../testdata"))
+	require.NoError(t, err)
+
+	hooks := templatehooks.NewCommonHooks()
+	renderer.RegisterPreHook(hooks.DeepDefaultsHook(map[string]any{
+		"app.name": "svc",
+		"app.env":  "dev",
+	}))
+
+	result, err := renderer.RenderString("{{ app.name }}@{{ app.env }}", map[string]any{
+		"app": map[string]any{"env": "prod"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "svc@prod", result)
+}
@@ -0,0 +1,311 @@
+package templatehooks
+
+import (
+	"errors"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goliatone/go-template"
+)
+
+// GoFormatHookName identifies FormatGoHook in *template.HookError values.
+const GoFormatHookName = "go-format"
+
+// GoFormatHookOption configures FormatGoHook behaviour.
+type GoFormatHookOption func(*GoFormatHookConfig)
+
+// GoFormatHookConfig captures settings for FormatGoHook.
+type GoFormatHookConfig struct {
+	SortImports         bool
+	RemoveUnusedImports bool
+	LocalPrefix         string
+	Condition           template.HookCondition
+}
+
+// WithGoSortImports merges import declarations into a single block grouped as
+// standard library, third party and, with WithGoLocalPrefix, local packages.
+func WithGoSortImports(enabled bool) GoFormatHookOption {
+	return func(cfg *GoFormatHookConfig) {
+		cfg.SortImports = enabled
+	}
+}
+
+// WithGoRemoveUnusedImports drops imports the file does not reference.
+func WithGoRemoveUnusedImports(enabled bool) GoFormatHookOption {
+	return func(cfg *GoFormatHookConfig) {
+		cfg.RemoveUnusedImports = enabled
+	}
+}
+
+// WithGoLocalPrefix groups imports starting with prefix after third party
+// imports. It implies WithGoSortImports.
+func WithGoLocalPrefix(prefix string) GoFormatHookOption {
+	return func(cfg *GoFormatHookConfig) {
+		cfg.LocalPrefix = prefix
+		cfg.SortImports = true
+	}
+}
+
+// WithGoFormatCondition sets a predicate governing when the hook executes.
+func WithGoFormatCondition(condition template.HookCondition) GoFormatHookOption {
+	return func(cfg *GoFormatHookConfig) {
+		cfg.Condition = condition
+	}
+}
+
+// FormatGoHook returns a post hook that formats Go output with go/format. It
+// runs for outputs whose ctx.OutputPath ends in ".go" or, without an output
+// path, that isGoFile detects. When the output does not parse the hook fails
+// with a *template.HookError carrying the line and column of the first error.
+//
+// Unused import removal guesses package names from import paths (the last
+// element, ignoring major version suffixes and "go-" prefixes). When the file
+// references a package no import accounts for, the guess is ambiguous and no
+// import is removed.
+func (h *CommonHooks) FormatGoHook(opts ...GoFormatHookOption) template.PostHook {
+	cfg := GoFormatHookConfig{
+		Condition: func(ctx *template.HookContext) bool {
+			if ctx.OutputPath != "" {
+				return strings.HasSuffix(ctx.OutputPath, ".go")
+			}
+			return isGoFile(ctx.TemplateName, ctx.Output)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(ctx *template.HookContext) (string, error) {
+		if cfg.Condition != nil && !cfg.Condition(ctx) {
+			return ctx.Output, nil
+		}
+
+		src := ctx.Output
+		var err error
+
+		if cfg.RemoveUnusedImports {
+			if src, err = removeUnusedImports(src); err != nil {
+				return "", goFormatError(ctx, err)
+			}
+		}
+
+		if cfg.SortImports {
+			if src, err = groupImports(src, cfg.LocalPrefix); err != nil {
+				return "", goFormatError(ctx, err)
+			}
+		}
+
+		formatted, err := format.Source([]byte(src))
+		if err != nil {
+			return "", goFormatError(ctx, err)
+		}
+
+		return string(formatted), nil
+	}
+}
+
+func goFormatError(ctx *template.HookContext, err error) error {
+	hookErr := &template.HookError{Hook: GoFormatHookName, TemplateName: ctx.TemplateName, Err: err}
+
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		hookErr.Line = list[0].Pos.Line
+		hookErr.Column = list[0].Pos.Column
+		hookErr.Err = errors.New(list[0].Msg)
+	}
+
+	return hookErr
+}
+
+func parseGo(src string) (*token.FileSet, *ast.File, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	return fset, file, err
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// importName returns the name an import is referenced by.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+
+	importPath, _ := strconv.Unquote(spec.Path.Value)
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && majorVersion.MatchString(name) {
+		name = elems[len(elems)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func removeUnusedImports(src string) (string, error) {
+	fset, file, err := parseGo(src)
+	if err != nil {
+		return "", err
+	}
+
+	// Package qualifiers are selector expressions on unresolved identifiers.
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	known := make(map[string]bool, len(file.Imports))
+	for _, spec := range file.Imports {
+		known[importName(spec)] = true
+	}
+	for name := range used {
+		if !known[name] {
+			// A reference to a package no import accounts for: an import
+			// name was guessed wrong, so removing anything is unsafe.
+			return src, nil
+		}
+	}
+
+	lines := strings.SplitAfter(src, "\n")
+	drop := make(map[int]bool)
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+
+		kept := 0
+		var unused []ast.Spec
+		for _, s := range gen.Specs {
+			spec := s.(*ast.ImportSpec)
+			name := importName(spec)
+			if name == "_" || name == "." || spec.Path.Value == `"C"` || used[name] {
+				kept++
+				continue
+			}
+			unused = append(unused, spec)
+		}
+
+		if kept == 0 && len(unused) > 0 {
+			markLines(fset, drop, gen.Pos(), gen.End())
+			continue
+		}
+		for _, spec := range unused {
+			markLines(fset, drop, spec.Pos(), spec.End())
+		}
+	}
+
+	if len(drop) == 0 {
+		return src, nil
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if !drop[i+1] {
+			b.WriteString(line)
+		}
+	}
+	return b.String(), nil
+}
+
+func markLines(fset *token.FileSet, drop map[int]bool, from, to token.Pos) {
+	for line := fset.Position(from).Line; line <= fset.Position(to).Line; line++ {
+		drop[line] = true
+	}
+}
+
+// groupImports rewrites all import declarations as one block grouped into
+// standard library, third party and local imports, each sorted by path.
+func groupImports(src, localPrefix string) (string, error) {
+	fset, file, err := parseGo(src)
+	if err != nil {
+		return "", err
+	}
+
+	var decls []*ast.GenDecl
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			break
+		}
+		decls = append(decls, gen)
+	}
+	if len(decls) == 0 {
+		return src, nil
+	}
+
+	type entry struct {
+		path string
+		text string
+	}
+	var groups [3][]entry
+
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+
+	for _, decl := range decls {
+		for _, s := range decl.Specs {
+			spec := s.(*ast.ImportSpec)
+			if spec.Path.Value == `"C"` {
+				// cgo preambles must stay attached to their own declaration.
+				return src, nil
+			}
+
+			start, end := spec.Pos(), spec.End()
+			if spec.Doc != nil {
+				start = spec.Doc.Pos()
+			}
+			if spec.Comment != nil {
+				end = spec.Comment.End()
+			}
+
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			group := 1
+			switch {
+			case localPrefix != "" && strings.HasPrefix(importPath, localPrefix):
+				group = 2
+			case !strings.Contains(strings.Split(importPath, "/")[0], "."):
+				group = 0
+			}
+			groups[group] = append(groups[group], entry{path: importPath, text: src[offset(start):offset(end)]})
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	first := true
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return group[i].path < group[j].path })
+		if !first {
+			b.WriteString("\n")
+		}
+		first = false
+		for _, e := range group {
+			b.WriteString("\t" + e.text + "\n")
+		}
+	}
+	b.WriteString(")")
+
+	start := offset(decls[0].Pos())
+	end := offset(decls[len(decls)-1].End())
+
+	return src[:start] + b.String() + src[end:], nil
+}
@@ -0,0 +1,103 @@
+package templatehooks_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/templatehooks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatGoHook(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithBaseDir("../testdata"))
+	require.NoError(t, err)
+
+	hooks := templatehooks.NewCommonHooks()
+	renderer.RegisterPostHook(hooks.FormatGoHook())
+
+	result, err := renderer.RenderString("package {{ pkg }}\nfunc  Hello( ) string {\nreturn   \"hi\"\n}\n", map[string]any{"pkg": "main"})
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc Hello() string {\n\treturn \"hi\"\n}\n", result)
+}
+
+func TestFormatGoHook_SkipsNonGoOutput(t *testing.T) {
+	hook := templatehooks.NewCommonHooks().FormatGoHook()
+
+	out, err := hook(&template.HookContext{TemplateName: "config.yaml", OutputPath: "config.yaml", Output: "package:  not go\n"})
+	require.NoError(t, err)
+	require.Equal(t, "package:  not go\n", out)
+}
+
+func TestFormatGoHook_Imports(t *testing.T) {
+	src := `package models
+
+import "strings"
+import (
+	"github.com/goliatone/go-template"
+	"example.com/app/internal/db" // storage
+	"fmt"
+	yaml "gopkg.in/yaml.v3"
+	"github.com/flosch/pongo2/v6"
+	_ "embed"
+	"os"
+)
+
+func Name(v string) string {
+	_ = template.ConvertToContext
+	_ = pongo2.Context{}
+	_ = db.Open
+	return fmt.Sprint(strings.ToUpper(v))
+}
+`
+	hook := templatehooks.NewCommonHooks().FormatGoHook(
+		templatehooks.WithGoRemoveUnusedImports(true),
+		templatehooks.WithGoLocalPrefix("example.com/app"),
+	)
+
+	out, err := hook(&template.HookContext{TemplateName: "model.go", OutputPath: "models/model.go", Output: src})
+	require.NoError(t, err)
+	require.Equal(t, `package models
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/goliatone/go-template"
+
+	"example.com/app/internal/db" // storage
+)
+
+func Name(v string) string {
+	_ = template.ConvertToContext
+	_ = pongo2.Context{}
+	_ = db.Open
+	return fmt.Sprint(strings.ToUpper(v))
+}
+`, out)
+}
+
+func TestFormatGoHook_AmbiguousImportsAreKept(t *testing.T) {
+	src := "package x\n\nimport (\n\t\"os\"\n\t\"example.com/weird-name\"\n)\n\nvar _ = weirdname.Thing\n"
+
+	hook := templatehooks.NewCommonHooks().FormatGoHook(templatehooks.WithGoRemoveUnusedImports(true))
+	out, err := hook(&template.HookContext{OutputPath: "x.go", Output: src})
+	require.NoError(t, err)
+	require.Contains(t, out, "\"os\"")
+}
+
+func TestFormatGoHook_ParseError(t *testing.T) {
+	hook := templatehooks.NewCommonHooks().FormatGoHook()
+
+	_, err := hook(&template.HookContext{TemplateName: "broken.go", OutputPath: "broken.go", Output: "package x\n\nfunc {\n"})
+
+	var hookErr *template.HookError
+	require.True(t, errors.As(err, &hookErr))
+	require.Equal(t, templatehooks.GoFormatHookName, hookErr.Hook)
+	require.Equal(t, "broken.go", hookErr.TemplateName)
+	require.Equal(t, 3, hookErr.Line)
+	require.Equal(t, 6, hookErr.Column)
+	require.Contains(t, err.Error(), "hook go-format (template broken.go) at line 3, column 6:")
+}
@@ -0,0 +1,160 @@
+package templatehooks
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// header is a comment block a header hook places at the top of an output.
+type header struct {
+	// lines are the rendered comment lines, without line endings.
+	lines []string
+	// separator is inserted between a newly added header and the output.
+	separator string
+	// equivalent reports whether an existing line stands for a generated
+	// one, e.g. the same message with a different timestamp.
+	equivalent func(existing, generated string) bool
+}
+
+var digitRun = regexp.MustCompile(`[0-9]+(?:\s*[-–]\s*[0-9]+)?`)
+
+// sameIgnoringNumbers treats lines differing only in numbers or number ranges,
+// such as dates and years, as equivalent.
+func sameIgnoringNumbers(existing, generated string) bool {
+	return digitRun.ReplaceAllString(existing, "0") == digitRun.ReplaceAllString(generated, "0")
+}
+
+// timestampPattern matches comment lines rendering messageFormat with any
+// timestamp.
+func timestampPattern(style CommentStyle, messageFormat string) func(existing, generated string) bool {
+	const placeholder = "\x00"
+	parts := strings.Split(style.LineComment(fmt.Sprintf(messageFormat, placeholder)), placeholder)
+	if len(parts) != 2 {
+		return sameIgnoringNumbers
+	}
+
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(strings.TrimRight(parts[0], " ")) + `\s*.+` + regexp.QuoteMeta(strings.TrimRight(parts[1], " ")) + "$")
+	return func(existing, _ string) bool {
+		return pattern.MatchString(existing)
+	}
+}
+
+// insertHeader adds hdr to output. An equivalent header already present in the
+// leading comments is replaced in place, or left alone when identical, so
+// running a header hook repeatedly never duplicates it. New headers go after
+// lines that must stay first: a shebang, an XML declaration or Go build
+// constraints.
+func insertHeader(output string, style CommentStyle, hdr header) string {
+	if len(hdr.lines) == 0 {
+		return output
+	}
+	if hdr.equivalent == nil {
+		hdr.equivalent = sameIgnoringNumbers
+	}
+
+	lines := strings.SplitAfter(output, "\n")
+	region, preamble := leadingRegion(lines, style)
+
+	for i := 0; i+len(hdr.lines) <= region; i++ {
+		if !matchesHeader(lines[i:i+len(hdr.lines)], hdr) {
+			continue
+		}
+
+		var b strings.Builder
+		for _, line := range lines[:i] {
+			b.WriteString(line)
+		}
+		for j, line := range hdr.lines {
+			b.WriteString(line)
+			b.WriteString(lineEnding(lines[i+j]))
+		}
+		for _, line := range lines[i+len(hdr.lines):] {
+			b.WriteString(line)
+		}
+		return b.String()
+	}
+
+	var b strings.Builder
+	for _, line := range lines[:preamble] {
+		b.WriteString(line)
+	}
+	if preamble > 0 && !strings.HasSuffix(lines[preamble-1], "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.Join(hdr.lines, "\n"))
+	b.WriteString("\n")
+	b.WriteString(hdr.separator)
+	for _, line := range lines[preamble:] {
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func matchesHeader(lines []string, hdr header) bool {
+	for i, line := range lines {
+		existing := strings.TrimRight(line, " \t\r\n")
+		generated := strings.TrimRight(hdr.lines[i], " \t")
+		if existing != generated && !hdr.equivalent(existing, generated) {
+			return false
+		}
+	}
+	return true
+}
+
+func lineEnding(line string) string {
+	if strings.HasSuffix(line, "\r\n") {
+		return "\r\n"
+	}
+	if strings.HasSuffix(line, "\n") {
+		return "\n"
+	}
+	return ""
+}
+
+// leadingRegion returns the number of leading lines made of comments, blank
+// lines and preamble lines, and the number of lines a new header must follow.
+func leadingRegion(lines []string, style CommentStyle) (region, preamble int) {
+	lineToken := strings.TrimSpace(style.Line)
+	if lineToken == "" && style.Block.Start == "" {
+		lineToken = strings.TrimSpace(style.Block.LinePrefix)
+	}
+	blockStart := strings.TrimSpace(style.Block.Start)
+	blockEnd := strings.TrimSpace(style.Block.End)
+
+	inBlock, inXMLDecl := false, false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case inXMLDecl:
+			inXMLDecl = !strings.Contains(trimmed, "?>")
+			preamble = i + 1
+		case inBlock:
+			inBlock = blockEnd == "" || !strings.Contains(trimmed, blockEnd)
+		case i == 0 && strings.HasPrefix(line, "#!"):
+			preamble = 1
+		case i == 0 && strings.HasPrefix(line, "<?xml"):
+			inXMLDecl = !strings.Contains(trimmed, "?>")
+			preamble = 1
+		case isBuildConstraint(trimmed):
+			preamble = i + 1
+			// Build constraints must be followed by a blank line.
+			if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" {
+				preamble = i + 2
+			}
+		case trimmed == "":
+		case blockStart != "" && strings.HasPrefix(trimmed, blockStart):
+			rest := strings.TrimPrefix(trimmed, blockStart)
+			inBlock = blockEnd == "" || !strings.Contains(rest, blockEnd)
+		case lineToken != "" && strings.HasPrefix(trimmed, lineToken):
+		default:
+			return i, preamble
+		}
+	}
+	return len(lines), preamble
+}
+
+func isBuildConstraint(line string) bool {
+	return strings.HasPrefix(line, "//go:build ") || strings.HasPrefix(line, "// +build ")
+}
@@ -0,0 +1,106 @@
+package templatehooks_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/templatehooks"
+	"github.com/stretchr/testify/require"
+)
+
+func runHook(t *testing.T, hook template.PostHook, ctx template.HookContext) string {
+	t.Helper()
+	out, err := hook(&ctx)
+	require.NoError(t, err)
+	return out
+}
+
+func TestHeaderHooks_Idempotent(t *testing.T) {
+	hooks := templatehooks.NewCommonHooks()
+	always := func(*template.HookContext) bool { return true }
+
+	copyright := hooks.AddCopyrightHook("Copyright 2024 ACME", templatehooks.WithCopyrightCondition(always))
+	license := hooks.AddLicenseHook("MIT License\n\nSee LICENSE.", templatehooks.WithLicenseCondition(always))
+	warning := hooks.AddGeneratedWarningHook()
+
+	ctx := template.HookContext{TemplateName: "model.go", Output: "package model\n"}
+	for _, hook := range []template.PostHook{copyright, license, warning} {
+		ctx.Output = runHook(t, hook, ctx)
+	}
+	once := ctx.Output
+
+	for _, hook := range []template.PostHook{copyright, license, warning} {
+		ctx.Output = runHook(t, hook, ctx)
+	}
+	require.Equal(t, once, ctx.Output)
+	require.Equal(t, "// Code generated by go-template. DO NOT EDIT.\n/*\n * MIT License\n\n * See LICENSE.\n */\n\n// Copyright 2024 ACME\npackage model\n", ctx.Output)
+}
+
+func TestHeaderHooks_ReplaceEquivalent(t *testing.T) {
+	hooks := templatehooks.NewCommonHooks()
+	always := func(*template.HookContext) bool { return true }
+
+	out := runHook(t, hooks.AddCopyrightHook("Copyright 2025 ACME", templatehooks.WithCopyrightCondition(always)),
+		template.HookContext{TemplateName: "main.py", Output: "# Copyright 2023 ACME\nprint(1)\n"})
+	require.Equal(t, "# Copyright 2025 ACME\nprint(1)\n", out)
+
+	timestamp := hooks.AddTimestampHook(
+		templatehooks.WithTimestampFormat(time.RFC822),
+		templatehooks.WithTimestampLocation(time.UTC),
+	)
+	out = runHook(t, timestamp, template.HookContext{
+		TemplateName: "main.go",
+		Output:       "// Generated on 02 Jan 06 15:04 UTC\npackage main\n",
+	})
+	require.Regexp(t, `^// Generated on [^\n]+\npackage main\n$`, out)
+	require.NotContains(t, out, "02 Jan 06")
+}
+
+func TestHeaderHooks_KeepPreambleFirst(t *testing.T) {
+	hooks := templatehooks.NewCommonHooks()
+	always := func(*template.HookContext) bool { return true }
+
+	tests := []struct {
+		name string
+		ctx  template.HookContext
+		hook template.PostHook
+		want string
+	}{
+		{
+			name: "shebang",
+			ctx:  template.HookContext{TemplateName: "run.sh", Output: "#!/bin/sh\necho hi\n"},
+			hook: hooks.AddLicenseHook("MIT", templatehooks.WithLicenseCondition(always)),
+			want: "#!/bin/sh\n# MIT\n\necho hi\n",
+		},
+		{
+			name: "go build constraints",
+			ctx:  template.HookContext{TemplateName: "linux.go", Output: "//go:build linux\n// +build linux\n\npackage sys\n"},
+			hook: hooks.AddLicenseHook("MIT", templatehooks.WithLicenseCondition(always)),
+			want: "//go:build linux\n// +build linux\n\n/*\n * MIT\n */\n\npackage sys\n",
+		},
+		{
+			name: "xml declaration",
+			ctx:  template.HookContext{TemplateName: "pom.xml", Output: "<?xml version=\"1.0\"?>\n<project/>\n"},
+			hook: hooks.AddGeneratedWarningHook(templatehooks.WithGeneratedWarningCondition(always)),
+			want: "<?xml version=\"1.0\"?>\n<!-- Code generated by go-template. DO NOT EDIT. -->\n<project/>\n",
+		},
+		{
+			name: "header after existing comments",
+			ctx:  template.HookContext{TemplateName: "linux.go", Output: "// Copyright ACME\n\n//go:build linux\n\npackage sys\n"},
+			hook: hooks.AddCopyrightHook("Copyright ACME", templatehooks.WithCopyrightCondition(always)),
+			want: "// Copyright ACME\n\n//go:build linux\n\npackage sys\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runHook(t, tt.hook, tt.ctx)
+			require.Equal(t, tt.want, out)
+
+			ctx := tt.ctx
+			ctx.Output = out
+			require.Equal(t, tt.want, runHook(t, tt.hook, ctx))
+		})
+	}
+}
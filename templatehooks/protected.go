@@ -0,0 +1,323 @@
+package templatehooks
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/goliatone/go-template"
+)
+
+// Default markers delimiting protected regions. They are matched anywhere on
+// a line so any comment syntax works:
+//
+//	// +protected begin:imports
+//	import "example.com/custom"
+//	// +protected end
+const (
+	DefaultProtectedBegin = "+protected begin:"
+	DefaultProtectedEnd   = "+protected end"
+)
+
+// ProtectedRegion is a named, hand written section of a generated file.
+type ProtectedRegion struct {
+	Name string
+	// Content holds the lines between the markers, line endings included.
+	Content string
+	// Line is the 1-based line of the begin marker.
+	Line int
+}
+
+// ProtectedRegionError reports malformed, duplicated or orphaned regions.
+type ProtectedRegionError struct {
+	// Path is the output path for regions of an existing file, or the template
+	// name for regions of rendered output. It is empty outside of hooks.
+	Path    string
+	Name    string
+	Line    int
+	Message string
+}
+
+func (e *ProtectedRegionError) Error() string {
+	var b strings.Builder
+	b.WriteString("protected region")
+	if e.Name != "" {
+		fmt.Fprintf(&b, " %q", e.Name)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&b, " in %s", e.Path)
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, " at line %d", e.Line)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ProtectedMarkers holds the begin and end markers of protected regions. The
+// begin marker is immediately followed by the region name; the end marker may
+// be followed by ":name", which must then match.
+type ProtectedMarkers struct {
+	Begin string
+	End   string
+}
+
+func (m ProtectedMarkers) compile() (*regexp.Regexp, *regexp.Regexp) {
+	if m.Begin == "" {
+		m.Begin = DefaultProtectedBegin
+	}
+	if m.End == "" {
+		m.End = DefaultProtectedEnd
+	}
+	begin := regexp.MustCompile(regexp.QuoteMeta(m.Begin) + `([\w.\-]+)`)
+	end := regexp.MustCompile(regexp.QuoteMeta(m.End) + `(?::([\w.\-]+))?\b`)
+	return begin, end
+}
+
+// ParseProtectedRegions extracts the protected regions of content using the
+// default markers.
+func ParseProtectedRegions(content string) ([]ProtectedRegion, error) {
+	return ProtectedMarkers{}.Parse(content)
+}
+
+// Parse extracts the protected regions of content. Nested, unterminated,
+// unopened and duplicated regions are reported as *ProtectedRegionError.
+func (m ProtectedMarkers) Parse(content string) ([]ProtectedRegion, error) {
+	beginRe, endRe := m.compile()
+
+	var regions []ProtectedRegion
+	seen := make(map[string]int)
+
+	var current *ProtectedRegion
+	var body strings.Builder
+
+	for i, line := range strings.SplitAfter(content, "\n") {
+		lineNo := i + 1
+
+		if match := beginRe.FindStringSubmatch(line); match != nil {
+			name := match[1]
+			if current != nil {
+				return nil, &ProtectedRegionError{Name: current.Name, Line: current.Line, Message: fmt.Sprintf("not closed before region %q at line %d", name, lineNo)}
+			}
+			if first, ok := seen[name]; ok {
+				return nil, &ProtectedRegionError{Name: name, Line: lineNo, Message: fmt.Sprintf("duplicates the region at line %d", first)}
+			}
+			seen[name] = lineNo
+			current = &ProtectedRegion{Name: name, Line: lineNo}
+			body.Reset()
+			continue
+		}
+
+		if match := endRe.FindStringSubmatch(line); match != nil {
+			if current == nil {
+				return nil, &ProtectedRegionError{Name: match[1], Line: lineNo, Message: "end marker without begin marker"}
+			}
+			if match[1] != "" && match[1] != current.Name {
+				return nil, &ProtectedRegionError{Name: current.Name, Line: current.Line, Message: fmt.Sprintf("closed by end marker for %q at line %d", match[1], lineNo)}
+			}
+			current.Content = body.String()
+			regions = append(regions, *current)
+			current = nil
+			continue
+		}
+
+		if current != nil {
+			body.WriteString(line)
+		}
+	}
+
+	if current != nil {
+		return nil, &ProtectedRegionError{Name: current.Name, Line: current.Line, Message: "begin marker without end marker"}
+	}
+
+	return regions, nil
+}
+
+// Merge returns generated with the content of every protected region replaced
+// by the content of the same region in existing. Regions of existing that the
+// generated output no longer declares are orphaned and reported as an error
+// unless dropOrphans is set, so hand written code is never silently lost.
+func (m ProtectedMarkers) Merge(generated, existing string, dropOrphans bool) (string, error) {
+	if _, err := m.Parse(generated); err != nil {
+		return "", err
+	}
+
+	previous, err := m.Parse(existing)
+	if err != nil {
+		return "", err
+	}
+	if len(previous) == 0 {
+		return generated, nil
+	}
+
+	kept := make(map[string]ProtectedRegion, len(previous))
+	for _, region := range previous {
+		kept[region.Name] = region
+	}
+
+	beginRe, endRe := m.compile()
+
+	var out, body strings.Builder
+	var current string
+	inRegion := false
+
+	for _, line := range strings.SplitAfter(generated, "\n") {
+		switch {
+		case !inRegion && beginRe.MatchString(line):
+			current, inRegion = beginRe.FindStringSubmatch(line)[1], true
+			body.Reset()
+			out.WriteString(line)
+		case inRegion && endRe.MatchString(line):
+			if region, ok := kept[current]; ok {
+				out.WriteString(region.Content)
+				delete(kept, current)
+			} else {
+				out.WriteString(body.String())
+			}
+			inRegion = false
+			out.WriteString(line)
+		case inRegion:
+			body.WriteString(line)
+		default:
+			out.WriteString(line)
+		}
+	}
+
+	if len(kept) > 0 && !dropOrphans {
+		var errs []error
+		for _, region := range previous {
+			if _, ok := kept[region.Name]; ok {
+				errs = append(errs, &ProtectedRegionError{Name: region.Name, Line: region.Line, Message: "no longer present in the generated output"})
+			}
+		}
+		return "", errors.Join(errs...)
+	}
+
+	return out.String(), nil
+}
+
+// ProtectedRegionsHookOption configures PreserveProtectedRegionsHook behaviour.
+type ProtectedRegionsHookOption func(*ProtectedRegionsHookConfig)
+
+// ProtectedRegionsHookConfig captures settings for PreserveProtectedRegionsHook.
+type ProtectedRegionsHookConfig struct {
+	Root        string
+	FS          fs.FS
+	Markers     ProtectedMarkers
+	DropOrphans bool
+	Condition   template.HookCondition
+}
+
+// WithProtectedRoot resolves HookContext.OutputPath relative to dir on disk.
+func WithProtectedRoot(dir string) ProtectedRegionsHookOption {
+	return func(cfg *ProtectedRegionsHookConfig) {
+		cfg.Root = dir
+	}
+}
+
+// WithProtectedFS reads existing output files from fsys instead of the disk.
+func WithProtectedFS(fsys fs.FS) ProtectedRegionsHookOption {
+	return func(cfg *ProtectedRegionsHookConfig) {
+		cfg.FS = fsys
+	}
+}
+
+// WithProtectedMarkers overrides the begin and end markers.
+func WithProtectedMarkers(begin, end string) ProtectedRegionsHookOption {
+	return func(cfg *ProtectedRegionsHookConfig) {
+		cfg.Markers = ProtectedMarkers{Begin: begin, End: end}
+	}
+}
+
+// WithProtectedDropOrphans discards regions of the existing file that the
+// template no longer declares instead of failing.
+func WithProtectedDropOrphans(enabled bool) ProtectedRegionsHookOption {
+	return func(cfg *ProtectedRegionsHookConfig) {
+		cfg.DropOrphans = enabled
+	}
+}
+
+// WithProtectedCondition sets a predicate governing when the hook executes.
+func WithProtectedCondition(condition template.HookCondition) ProtectedRegionsHookOption {
+	return func(cfg *ProtectedRegionsHookConfig) {
+		cfg.Condition = condition
+	}
+}
+
+// PreserveProtectedRegionsHook returns a post hook that re-injects protected
+// regions from the file at ctx.OutputPath into the rendered output. Renders
+// without an output path, or whose file does not exist yet, only have their
+// markers validated. Errors are *ProtectedRegionError values carrying the
+// offending file path.
+func (h *CommonHooks) PreserveProtectedRegionsHook(opts ...ProtectedRegionsHookOption) template.PostHook {
+	cfg := ProtectedRegionsHookConfig{}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(ctx *template.HookContext) (string, error) {
+		if cfg.Condition != nil && !cfg.Condition(ctx) {
+			return ctx.Output, nil
+		}
+
+		if _, err := cfg.Markers.Parse(ctx.Output); err != nil {
+			return "", withRegionPath(err, ctx.TemplateName)
+		}
+
+		existing, err := readExistingOutput(cfg.FS, cfg.Root, ctx.OutputPath)
+		if err != nil || existing == nil {
+			return ctx.Output, err
+		}
+
+		merged, err := cfg.Markers.Merge(ctx.Output, *existing, cfg.DropOrphans)
+		if err != nil {
+			return "", withRegionPath(err, ctx.OutputPath)
+		}
+		return merged, nil
+	}
+}
+
+// readExistingOutput reads the file at outputPath from fsys, or from root on
+// disk when fsys is nil. It returns nil when there is no such file.
+func readExistingOutput(fsys fs.FS, root, outputPath string) (*string, error) {
+	if outputPath == "" {
+		return nil, nil
+	}
+
+	var content []byte
+	var err error
+	if fsys != nil {
+		content, err = fs.ReadFile(fsys, filepath.ToSlash(outputPath))
+	} else {
+		content, err = os.ReadFile(filepath.Join(root, filepath.FromSlash(outputPath)))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read existing output %s: %w", outputPath, err)
+	}
+
+	existing := string(content)
+	return &existing, nil
+}
+
+// withRegionPath sets Path on every *ProtectedRegionError in err.
+func withRegionPath(err error, p string) error {
+	var regionErr *ProtectedRegionError
+	if errors.As(err, &regionErr) && regionErr.Path == "" {
+		regionErr.Path = p
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			withRegionPath(e, p)
+		}
+	}
+	return err
+}
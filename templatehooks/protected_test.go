@@ -0,0 +1,140 @@
+package templatehooks_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/templatehooks"
+	"github.com/stretchr/testify/require"
+)
+
+const protectedTemplate = `package {{ pkg }}
+
+// +protected begin:imports
+// add imports here
+// +protected end
+
+func Generated() {}
+
+// +protected begin:methods
+// +protected end:methods
+`
+
+func TestPreserveProtectedRegionsHook(t *testing.T) {
+	existing := fstest.MapFS{
+		"models/user.go": {Data: []byte(`package old
+
+// +protected begin:imports
+import "strings"
+// +protected end
+
+func Old() {}
+
+// +protected begin:methods
+func (u User) Name() string { return strings.ToUpper(u.name) }
+// +protected end:methods
+`)},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{
+		"model.go.tpl": {Data: []byte(protectedTemplate)},
+	}))
+	require.NoError(t, err)
+
+	hooks := templatehooks.NewCommonHooks()
+	renderer.RegisterPostHook(hooks.PreserveProtectedRegionsHook(templatehooks.WithProtectedFS(existing)))
+
+	file, err := renderer.RenderFile("model.go", map[string]any{"pkg": "models"}, template.WithOutputPath("models/user.go"))
+	require.NoError(t, err)
+	require.Equal(t, `package models
+
+// +protected begin:imports
+import "strings"
+// +protected end
+
+func Generated() {}
+
+// +protected begin:methods
+func (u User) Name() string { return strings.ToUpper(u.name) }
+// +protected end:methods
+`, string(file.Content))
+
+	// A new file keeps the template defaults.
+	file, err = renderer.RenderFile("model.go", map[string]any{"pkg": "models"}, template.WithOutputPath("models/new.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(file.Content), "// add imports here\n")
+}
+
+func TestPreserveProtectedRegionsHook_OrphanedRegion(t *testing.T) {
+	existing := "// +protected begin:helpers\nfunc helper() {}\n// +protected end\n"
+
+	hook := templatehooks.NewCommonHooks().PreserveProtectedRegionsHook(
+		templatehooks.WithProtectedFS(fstest.MapFS{"out.go": {Data: []byte(existing)}}),
+	)
+
+	_, err := hook(&template.HookContext{TemplateName: "out.go", OutputPath: "out.go", Output: "package x\n"})
+	var regionErr *templatehooks.ProtectedRegionError
+	require.True(t, errors.As(err, &regionErr))
+	require.Equal(t, "helpers", regionErr.Name)
+	require.Equal(t, "out.go", regionErr.Path)
+	require.EqualError(t, err, `protected region "helpers" in out.go at line 1: no longer present in the generated output`)
+
+	dropping := templatehooks.NewCommonHooks().PreserveProtectedRegionsHook(
+		templatehooks.WithProtectedFS(fstest.MapFS{"out.go": {Data: []byte(existing)}}),
+		templatehooks.WithProtectedDropOrphans(true),
+	)
+	out, err := dropping(&template.HookContext{OutputPath: "out.go", Output: "package x\n"})
+	require.NoError(t, err)
+	require.Equal(t, "package x\n", out)
+}
+
+func TestParseProtectedRegions_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{
+			name:    "duplicate",
+			content: "# +protected begin:a\n# +protected end\n# +protected begin:a\n# +protected end\n",
+			message: `protected region "a" at line 3: duplicates the region at line 1`,
+		},
+		{
+			name:    "unterminated",
+			content: "<!-- +protected begin:body -->\n<p>x</p>\n",
+			message: `protected region "body" at line 1: begin marker without end marker`,
+		},
+		{
+			name:    "end without begin",
+			content: "x\n// +protected end\n",
+			message: `protected region at line 2: end marker without begin marker`,
+		},
+		{
+			name:    "nested",
+			content: "// +protected begin:a\n// +protected begin:b\n// +protected end\n",
+			message: `protected region "a" at line 1: not closed before region "b" at line 2`,
+		},
+		{
+			name:    "mismatched end",
+			content: "// +protected begin:a\n// +protected end:b\n",
+			message: `protected region "a" at line 1: closed by end marker for "b" at line 2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := templatehooks.ParseProtectedRegions(tt.content)
+			require.EqualError(t, err, tt.message)
+		})
+	}
+}
+
+func TestProtectedMarkers_Custom(t *testing.T) {
+	markers := templatehooks.ProtectedMarkers{Begin: "KEEP:", End: "/KEEP"}
+
+	merged, err := markers.Merge("a\n# KEEP:x\ndefault\n# /KEEP\nb\n", "# KEEP:x\nmine\n# /KEEP\n", false)
+	require.NoError(t, err)
+	require.Equal(t, "a\n# KEEP:x\nmine\n# /KEEP\nb\n", merged)
+}
@@ -0,0 +1,571 @@
+package templatehooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goliatone/go-template"
+)
+
+// Schema is a compiled JSON Schema supporting the subset of draft 2020-12 that
+// matters for template data: type, enum, const, required, properties,
+// additionalProperties, items, prefixItems, pattern, length/size bounds,
+// numeric bounds and local $ref to $defs.
+type Schema struct {
+	root *schemaNode
+}
+
+type schemaNode struct {
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*schemaNode `json:"$defs"`
+	Type                 schemaTypes            `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Const                *any                   `json:"const"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	AdditionalProperties *schemaBool            `json:"additionalProperties"`
+	Items                *schemaNode            `json:"items"`
+	PrefixItems          []*schemaNode          `json:"prefixItems"`
+	Pattern              string                 `json:"pattern"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	UniqueItems          bool                   `json:"uniqueItems"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes accepts both "type": "string" and "type": ["string", "null"].
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+// schemaBool accepts a boolean or a schema, as used by additionalProperties.
+type schemaBool struct {
+	allowed bool
+	schema  *schemaNode
+}
+
+func (s *schemaBool) UnmarshalJSON(b []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(b, &allowed); err == nil {
+		s.allowed = allowed
+		return nil
+	}
+	s.allowed = true
+	s.schema = &schemaNode{}
+	return json.Unmarshal(b, s.schema)
+}
+
+// SchemaViolation describes a single validation failure. Pointer is a JSON
+// pointer (RFC 6901) to the offending value, "" being the document root.
+type SchemaViolation struct {
+	Pointer string
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// SchemaValidationError aggregates every violation found for a render.
+type SchemaValidationError struct {
+	TemplateName string
+	Violations   []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.String())
+	}
+
+	prefix := "schema validation failed"
+	if e.TemplateName != "" {
+		prefix += " for " + e.TemplateName
+	}
+	return prefix + ": " + strings.Join(parts, "; ")
+}
+
+// CompileSchema parses a JSON Schema document.
+func CompileSchema(data []byte) (*Schema, error) {
+	root := &schemaNode{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	if err := root.compile(root, ""); err != nil {
+		return nil, err
+	}
+
+	return &Schema{root: root}, nil
+}
+
+// LoadSchema reads and compiles a JSON Schema file. When fsys is nil the path
+// is read from the OS file system.
+func LoadSchema(fsys fs.FS, path string) (*Schema, error) {
+	var data []byte
+	var err error
+	if fsys == nil {
+		data, err = os.ReadFile(path)
+	} else {
+		data, err = fs.ReadFile(fsys, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read schema %s: %w", path, err)
+	}
+
+	schema, err := CompileSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+func (n *schemaNode) compile(root *schemaNode, pointer string) error {
+	if n.Ref != "" {
+		if err := n.checkRefChain(root); err != nil {
+			return fmt.Errorf("invalid schema at %q: %w", pointer, err)
+		}
+	}
+
+	if n.Pattern != "" {
+		re, err := regexp.Compile(n.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema at %q: bad pattern: %w", pointer, err)
+		}
+		n.pattern = re
+	}
+
+	for name, child := range n.Defs {
+		if err := child.compile(root, pointer+"/$defs/"+escapePointer(name)); err != nil {
+			return err
+		}
+	}
+	for name, child := range n.Properties {
+		if err := child.compile(root, pointer+"/properties/"+escapePointer(name)); err != nil {
+			return err
+		}
+	}
+	if n.AdditionalProperties != nil && n.AdditionalProperties.schema != nil {
+		if err := n.AdditionalProperties.schema.compile(root, pointer+"/additionalProperties"); err != nil {
+			return err
+		}
+	}
+	if n.Items != nil {
+		if err := n.Items.compile(root, pointer+"/items"); err != nil {
+			return err
+		}
+	}
+	for i, child := range n.PrefixItems {
+		if err := child.compile(root, pointer+"/prefixItems/"+strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkRefChain follows the $ref chain starting at n. A chain leading back to
+// a node it already visited would validate the same value forever, so it is
+// rejected; recursion through properties or items is fine as it descends into
+// the value.
+func (n *schemaNode) checkRefChain(root *schemaNode) error {
+	seen := map[*schemaNode]bool{n: true}
+	for node := n; node.Ref != ""; {
+		target, err := root.resolve(node.Ref)
+		if err != nil {
+			return err
+		}
+		if seen[target] {
+			return fmt.Errorf("circular $ref %q", node.Ref)
+		}
+		seen[target] = true
+		node = target
+	}
+	return nil
+}
+
+func (n *schemaNode) resolve(ref string) (*schemaNode, error) {
+	if ref == "#" {
+		return n, nil
+	}
+
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q, only local #/$defs references are supported", ref)
+	}
+
+	def, ok := n.Defs[unescapePointer(name)]
+	if !ok {
+		return nil, fmt.Errorf("unresolved $ref %q", ref)
+	}
+	return def, nil
+}
+
+// Validate checks value against the schema and returns every violation found.
+// Values are normalised through JSON first, so structs are validated using
+// their json tags.
+func (s *Schema) Validate(value any) []SchemaViolation {
+	if s == nil || s.root == nil {
+		return nil
+	}
+
+	normalised, err := normaliseJSON(value)
+	if err != nil {
+		return []SchemaViolation{{Message: err.Error()}}
+	}
+
+	var out []SchemaViolation
+	s.root.validate(s.root, normalised, "", &out)
+	return out
+}
+
+func (n *schemaNode) validate(root *schemaNode, value any, pointer string, out *[]SchemaViolation) {
+	report := func(format string, args ...any) {
+		*out = append(*out, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n.Ref != "" {
+		target, err := root.resolve(n.Ref)
+		if err != nil {
+			report("%v", err)
+			return
+		}
+		target.validate(root, value, pointer, out)
+	}
+
+	if len(n.Type) > 0 && !matchesAnyType(value, n.Type) {
+		report("expected %s, got %s", strings.Join(n.Type, " or "), jsonType(value))
+		return
+	}
+
+	if len(n.Enum) > 0 {
+		found := false
+		for _, candidate := range n.Enum {
+			if reflect.DeepEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("value %s is not one of %s", formatJSON(value), formatJSON(n.Enum))
+		}
+	}
+
+	if n.Const != nil && !reflect.DeepEqual(*n.Const, value) {
+		report("value must be %s", formatJSON(*n.Const))
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if n.MinLength != nil && length < *n.MinLength {
+			report("string is shorter than %d characters", *n.MinLength)
+		}
+		if n.MaxLength != nil && length > *n.MaxLength {
+			report("string is longer than %d characters", *n.MaxLength)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			report("string %q does not match pattern %q", v, n.Pattern)
+		}
+	case float64:
+		if n.Minimum != nil && v < *n.Minimum {
+			report("number must be >= %v", *n.Minimum)
+		}
+		if n.Maximum != nil && v > *n.Maximum {
+			report("number must be <= %v", *n.Maximum)
+		}
+		if n.ExclusiveMinimum != nil && v <= *n.ExclusiveMinimum {
+			report("number must be > %v", *n.ExclusiveMinimum)
+		}
+		if n.ExclusiveMaximum != nil && v >= *n.ExclusiveMaximum {
+			report("number must be < %v", *n.ExclusiveMaximum)
+		}
+	case map[string]any:
+		for _, name := range n.Required {
+			if _, ok := v[name]; !ok {
+				report("missing required property %q", name)
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := pointer + "/" + escapePointer(key)
+			if prop, ok := n.Properties[key]; ok {
+				prop.validate(root, v[key], child, out)
+				continue
+			}
+			if n.AdditionalProperties == nil {
+				continue
+			}
+			if !n.AdditionalProperties.allowed {
+				*out = append(*out, SchemaViolation{Pointer: child, Message: "additional property is not allowed"})
+				continue
+			}
+			if n.AdditionalProperties.schema != nil {
+				n.AdditionalProperties.schema.validate(root, v[key], child, out)
+			}
+		}
+	case []any:
+		if n.MinItems != nil && len(v) < *n.MinItems {
+			report("array has fewer than %d items", *n.MinItems)
+		}
+		if n.MaxItems != nil && len(v) > *n.MaxItems {
+			report("array has more than %d items", *n.MaxItems)
+		}
+		if n.UniqueItems {
+			for i := range v {
+				for j := 0; j < i; j++ {
+					if reflect.DeepEqual(v[i], v[j]) {
+						report("array items %d and %d are equal", j, i)
+					}
+				}
+			}
+		}
+		for i, item := range v {
+			child := pointer + "/" + strconv.Itoa(i)
+			if i < len(n.PrefixItems) {
+				n.PrefixItems[i].validate(root, item, child, out)
+				continue
+			}
+			if n.Items != nil {
+				n.Items.validate(root, item, child, out)
+			}
+		}
+	}
+}
+
+func matchesAnyType(value any, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(value any, t string) bool {
+	switch t {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == t
+	}
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func normaliseJSON(value any) (any, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode data: %w", err)
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("unable to decode data: %w", err)
+	}
+	return out, nil
+}
+
+func formatJSON(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func unescapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// SchemaHookOption configures ValidateSchemaHook behaviour.
+type SchemaHookOption func(*SchemaHookConfig)
+
+// SchemaHookConfig captures settings for ValidateSchemaHook.
+type SchemaHookConfig struct {
+	Schema     *Schema
+	SchemaFile string
+	FS         fs.FS
+	Suffix     string
+	Condition  template.HookCondition
+}
+
+// WithSchema validates every render against an already compiled schema.
+func WithSchema(schema *Schema) SchemaHookOption {
+	return func(cfg *SchemaHookConfig) {
+		cfg.Schema = schema
+	}
+}
+
+// WithSchemaFile loads the schema from path, relative to the FS configured with
+// WithSchemaFS or the OS file system otherwise. The file is read on first use.
+func WithSchemaFile(path string) SchemaHookOption {
+	return func(cfg *SchemaHookConfig) {
+		cfg.SchemaFile = path
+	}
+}
+
+// WithSchemaFS sets the file system used to resolve schema files. When no
+// explicit schema is configured the hook looks for a schema stored alongside
+// the template, e.g. "model.go.tpl" -> "model.go.schema.json".
+func WithSchemaFS(fsys fs.FS) SchemaHookOption {
+	return func(cfg *SchemaHookConfig) {
+		cfg.FS = fsys
+	}
+}
+
+// WithSchemaSuffix overrides the suffix used to find schemas stored alongside
+// templates (".schema.json" by default).
+func WithSchemaSuffix(suffix string) SchemaHookOption {
+	return func(cfg *SchemaHookConfig) {
+		cfg.Suffix = suffix
+	}
+}
+
+// WithSchemaCondition sets a predicate governing when the hook executes.
+func WithSchemaCondition(condition template.HookCondition) SchemaHookOption {
+	return func(cfg *SchemaHookConfig) {
+		cfg.Condition = condition
+	}
+}
+
+// ValidateSchemaHook returns a pre hook that validates ctx.Data against a JSON
+// Schema. Every violation is reported in a single *SchemaValidationError. When
+// the hook resolves schemas alongside templates and none exists for the
+// current template, the render proceeds unchecked. ctx.Data is not modified.
+func (h *CommonHooks) ValidateSchemaHook(opts ...SchemaHookOption) template.PreHook {
+	cfg := SchemaHookConfig{
+		Suffix: ".schema.json",
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var cache sync.Map
+
+	load := func(path string) (*Schema, error) {
+		if cached, ok := cache.Load(path); ok {
+			return cached.(*Schema), nil
+		}
+		schema, err := LoadSchema(cfg.FS, path)
+		if err != nil {
+			return nil, err
+		}
+		cache.Store(path, schema)
+		return schema, nil
+	}
+
+	return func(ctx *template.HookContext) error {
+		if cfg.Condition != nil && !cfg.Condition(ctx) {
+			return nil
+		}
+
+		schema := cfg.Schema
+		switch {
+		case schema != nil:
+		case cfg.SchemaFile != "":
+			s, err := load(cfg.SchemaFile)
+			if err != nil {
+				return err
+			}
+			schema = s
+		case cfg.FS != nil && ctx.TemplateName != "":
+			path := schemaPathFor(ctx, cfg.Suffix)
+			if _, err := fs.Stat(cfg.FS, path); err != nil {
+				return nil
+			}
+			s, err := load(path)
+			if err != nil {
+				return err
+			}
+			schema = s
+		default:
+			return nil
+		}
+
+		data := ctx.Data
+		if data == nil {
+			// the engine renders nil data as an empty context
+			data = map[string]any{}
+		}
+
+		if violations := schema.Validate(data); len(violations) > 0 {
+			return &SchemaValidationError{
+				TemplateName: ctx.TemplateName,
+				Violations:   violations,
+			}
+		}
+		return nil
+	}
+}
+
+func schemaPathFor(ctx *template.HookContext, suffix string) string {
+	name := ctx.TemplateName
+	if ext, ok := ctx.Metadata["ext"].(string); ok && ext != "" {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name + suffix
+}
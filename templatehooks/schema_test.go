@@ -0,0 +1,208 @@
+package templatehooks_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/templatehooks"
+	"github.com/stretchr/testify/require"
+)
+
+const serviceSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["name", "version", "config"],
+	"properties": {
+		"name": {"type": "string", "pattern": "^[a-z][a-z0-9-]*$"},
+		"version": {"type": "string"},
+		"environment": {"enum": ["dev", "staging", "production"]},
+		"replicas": {"type": "integer", "minimum": 1},
+		"config": {
+			"type": "object",
+			"required": ["db"],
+			"properties": {
+				"db": {"$ref": "#/$defs/db"}
+			}
+		},
+		"features": {
+			"type": "array",
+			"items": {"type": "string", "minLength": 2},
+			"uniqueItems": true
+		}
+	},
+	"$defs": {
+		"db": {
+			"type": "object",
+			"required": ["port"],
+			"additionalProperties": false,
+			"properties": {
+				"host": {"type": "string"},
+				"port": {"type": "integer", "maximum": 65535}
+			}
+		}
+	}
+}`
+
+func TestSchema_ValidateCollectsAllViolations(t *testing.T) {
+	schema, err := templatehooks.CompileSchema([]byte(serviceSchema))
+	require.NoError(t, err)
+
+	violations := schema.Validate(map[string]any{
+		"name":        "Bad Name",
+		"environment": "qa",
+		"replicas":    1.5,
+		"config": map[string]any{
+			"db": map[string]any{"port": 70000, "user": "root"},
+		},
+		"features": []any{"auth", "x", "auth"},
+	})
+
+	got := map[string]string{}
+	for _, v := range violations {
+		got[v.Pointer] = v.Message
+	}
+
+	require.Contains(t, got, "")
+	require.Contains(t, got[""], `"version"`)
+	require.Contains(t, got, "/name")
+	require.Contains(t, got, "/environment")
+	require.Contains(t, got, "/replicas")
+	require.Contains(t, got, "/config/db/port")
+	require.Contains(t, got, "/config/db/user")
+	require.Contains(t, got, "/features/1")
+	require.Contains(t, got, "/features")
+}
+
+func TestSchema_ValidateStructData(t *testing.T) {
+	schema, err := templatehooks.CompileSchema([]byte(`{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}`))
+	require.NoError(t, err)
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	require.Empty(t, schema.Validate(payload{Name: "ok"}))
+	require.NotEmpty(t, schema.Validate(struct{}{}))
+}
+
+func TestCompileSchema_Errors(t *testing.T) {
+	_, err := templatehooks.CompileSchema([]byte(`{"type": "string", "pattern": "("}`))
+	require.Error(t, err)
+
+	_, err = templatehooks.CompileSchema([]byte(`{"$ref": "#/$defs/missing"}`))
+	require.Error(t, err)
+
+	_, err = templatehooks.CompileSchema([]byte(`{"$ref": "https://example.com/schema.json"}`))
+	require.Error(t, err)
+
+	_, err = templatehooks.CompileSchema([]byte(`{"$ref": "#"}`))
+	require.ErrorContains(t, err, `circular $ref "#"`)
+
+	_, err = templatehooks.CompileSchema([]byte(`{"$defs": {"a": {"$ref": "#/$defs/a"}}}`))
+	require.ErrorContains(t, err, "circular $ref")
+
+	_, err = templatehooks.CompileSchema([]byte(`{"properties": {"x": {"$ref": "#/$defs/a"}}, "$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}}`))
+	require.ErrorContains(t, err, "circular $ref")
+}
+
+func TestSchema_RecursiveRef(t *testing.T) {
+	schema, err := templatehooks.CompileSchema([]byte(`{
+		"$ref": "#/$defs/node",
+		"$defs": {
+			"node": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	violations := schema.Validate(map[string]any{
+		"name": "root",
+		"children": []any{
+			map[string]any{"name": "leaf", "children": []any{map[string]any{"name": 1}}},
+		},
+	})
+	require.Len(t, violations, 1)
+	require.Equal(t, "/children/0/children/0/name", violations[0].Pointer)
+}
+
+func TestCommonHooks_ValidateSchemaHook(t *testing.T) {
+	schema, err := templatehooks.CompileSchema([]byte(`{
+		"type": "object",
+		"required": ["package_name", "struct_name"],
+		"properties": {"package_name": {"type": "string", "pattern": "^[a-z]+$"}}
+	}`))
+	require.NoError(t, err)
+
+	renderer, err := template.NewRenderer(template.WithBaseDir("../testdata"))
+	require.NoError(t, err)
+
+	hooks := templatehooks.NewCommonHooks()
+	renderer.RegisterPreHook(hooks.ValidateSchemaHook(templatehooks.WithSchema(schema)))
+
+	_, err = renderer.RenderTemplate("code.go", map[string]any{"package_name": "Main"})
+	require.Error(t, err)
+
+	var schemaErr *templatehooks.SchemaValidationError
+	require.True(t, errors.As(err, &schemaErr))
+	require.Len(t, schemaErr.Violations, 2)
+	require.Contains(t, err.Error(), "/package_name")
+
+	_, err = renderer.RenderTemplate("code.go", map[string]any{
+		"package_name": "main",
+		"struct_name":  "Config",
+	})
+	require.NoError(t, err)
+}
+
+func TestCommonHooks_ValidateSchemaHook_Alongside(t *testing.T) {
+	fsys := fstest.MapFS{
+		"greet.tpl":         {Data: []byte("Hello {{ name }}")},
+		"greet.schema.json": {Data: []byte(`{"type": "object", "required": ["name"]}`)},
+		"other.tpl":         {Data: []byte("no schema")},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	hooks := templatehooks.NewCommonHooks()
+	renderer.RegisterPreHook(hooks.ValidateSchemaHook(templatehooks.WithSchemaFS(fsys)))
+
+	_, err = renderer.RenderTemplate("greet", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `missing required property "name"`)
+
+	result, err := renderer.RenderTemplate("greet", map[string]any{"name": "Ada"})
+	require.NoError(t, err)
+	require.Equal(t, "Hello Ada", result)
+
+	_, err = renderer.RenderTemplate("other", nil)
+	require.NoError(t, err)
+}
+
+func TestCommonHooks_ValidateSchemaHook_File(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schemas/config.json": {Data: []byte(`{"type": "object", "required": ["environment"]}`)},
+	}
+
+	hooks := templatehooks.NewCommonHooks()
+	hook := hooks.ValidateSchemaHook(
+		templatehooks.WithSchemaFS(fsys),
+		templatehooks.WithSchemaFile("schemas/config.json"),
+	)
+
+	err := hook(&template.HookContext{Data: map[string]any{"environment": "dev"}, Metadata: map[string]any{}})
+	require.NoError(t, err)
+
+	err = hook(&template.HookContext{Data: map[string]any{}, Metadata: map[string]any{}})
+	require.Error(t, err)
+
+	missing := hooks.ValidateSchemaHook(templatehooks.WithSchemaFile("does/not/exist.json"))
+	require.Error(t, missing(&template.HookContext{Metadata: map[string]any{}}))
+}
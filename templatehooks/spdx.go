@@ -0,0 +1,204 @@
+package templatehooks
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goliatone/go-template"
+)
+
+//go:embed licenses/*.txt
+var licenseNotices embed.FS
+
+// LicenseNotice returns the bundled short notice for an SPDX license
+// identifier, without its copyright line.
+func LicenseNotice(identifier string) (string, bool) {
+	content, err := licenseNotices.ReadFile("licenses/" + identifier + ".txt")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimRight(string(content), "\n"), true
+}
+
+// LicenseNotices lists the SPDX identifiers with a bundled notice.
+func LicenseNotices() []string {
+	entries, _ := fs.ReadDir(licenseNotices, "licenses")
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, strings.TrimSuffix(entry.Name(), ".txt"))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SPDXHookOption configures AddSPDXHeaderHook behaviour.
+type SPDXHookOption func(*SPDXHookConfig)
+
+// SPDXHookConfig captures settings for AddSPDXHeaderHook.
+type SPDXHookConfig struct {
+	Holder    string
+	Year      int
+	Notice    bool
+	Root      string
+	FS        fs.FS
+	Style     *CommentStyle
+	Condition template.HookCondition
+}
+
+// WithSPDXHolder adds a "Copyright (c) <year> <holder>" line.
+func WithSPDXHolder(holder string) SPDXHookOption {
+	return func(cfg *SPDXHookConfig) {
+		cfg.Holder = holder
+	}
+}
+
+// WithSPDXYear sets the copyright year, the current year by default.
+func WithSPDXYear(year int) SPDXHookOption {
+	return func(cfg *SPDXHookConfig) {
+		cfg.Year = year
+	}
+}
+
+// WithSPDXNotice adds the bundled short notice of the license after the
+// identifier, see LicenseNotices.
+func WithSPDXNotice(enabled bool) SPDXHookOption {
+	return func(cfg *SPDXHookConfig) {
+		cfg.Notice = enabled
+	}
+}
+
+// WithSPDXRoot resolves HookContext.OutputPath relative to dir on disk when
+// looking for the first copyright year of an existing file.
+func WithSPDXRoot(dir string) SPDXHookOption {
+	return func(cfg *SPDXHookConfig) {
+		cfg.Root = dir
+	}
+}
+
+// WithSPDXFS reads existing output files from fsys instead of the disk.
+func WithSPDXFS(fsys fs.FS) SPDXHookOption {
+	return func(cfg *SPDXHookConfig) {
+		cfg.FS = fsys
+	}
+}
+
+// WithSPDXCommentStyle overrides the comment style otherwise picked from the
+// comment style registry. Headers are always written as line comments.
+func WithSPDXCommentStyle(style CommentStyle) SPDXHookOption {
+	return func(cfg *SPDXHookConfig) {
+		cfg.Style = &style
+	}
+}
+
+// WithSPDXCondition sets a predicate governing when the hook executes.
+func WithSPDXCondition(condition template.HookCondition) SPDXHookOption {
+	return func(cfg *SPDXHookConfig) {
+		cfg.Condition = condition
+	}
+}
+
+// AddSPDXHeaderHook returns a post hook that prepends an
+// "SPDX-License-Identifier: <identifier>" header, optionally followed by a
+// copyright line and the license's bundled notice. When the file at
+// ctx.OutputPath, or the output itself, already names a copyright year, the
+// copyright line spans from that year to the current one, e.g. "2021-2025".
+// Like the other header hooks it replaces an existing equivalent header rather
+// than adding a second one.
+func (h *CommonHooks) AddSPDXHeaderHook(identifier string, opts ...SPDXHookOption) template.PostHook {
+	cfg := SPDXHookConfig{
+		Condition: func(ctx *template.HookContext) bool {
+			return isCodeFile(ctx.TemplateName, ctx.Output)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(ctx *template.HookContext) (string, error) {
+		if identifier == "" {
+			return ctx.Output, nil
+		}
+
+		if cfg.Condition != nil && !cfg.Condition(ctx) {
+			return ctx.Output, nil
+		}
+
+		lines := []string{"SPDX-License-Identifier: " + identifier}
+
+		if cfg.Holder != "" {
+			year := cfg.Year
+			if year == 0 {
+				year = h.now(ctx).Year()
+			}
+
+			first, err := cfg.firstYear(ctx)
+			if err != nil {
+				return "", err
+			}
+
+			years := strconv.Itoa(year)
+			if first != 0 && first < year {
+				years = fmt.Sprintf("%d-%d", first, year)
+			}
+			lines = append(lines, fmt.Sprintf("Copyright (c) %s %s", years, cfg.Holder))
+		}
+
+		if cfg.Notice {
+			notice, ok := LicenseNotice(identifier)
+			if !ok {
+				return "", fmt.Errorf("no bundled notice for license %q, available: %s",
+					identifier, strings.Join(LicenseNotices(), ", "))
+			}
+			lines = append(lines, "")
+			lines = append(lines, strings.Split(notice, "\n")...)
+		}
+
+		style := h.commentStyle(ctx, defaultCommentStyle)
+		if cfg.Style != nil {
+			style = *cfg.Style
+		}
+		style.Block = CommentBlockStyle{}
+
+		block := style.BuildBlock(lines)
+		return insertHeader(ctx.Output, style, header{
+			lines:     strings.Split(strings.TrimSuffix(block, "\n"), "\n"),
+			separator: "\n",
+		}), nil
+	}
+}
+
+var copyrightYears = regexp.MustCompile(`(?i)copyright\s*(?:\(c\)|©)?\s*((?:19|20)[0-9]{2})`)
+
+// firstYear returns the first copyright year named in the existing output
+// file, or else in the rendered output, and 0 when there is none.
+func (cfg *SPDXHookConfig) firstYear(ctx *template.HookContext) (int, error) {
+	existing, err := readExistingOutput(cfg.FS, cfg.Root, ctx.OutputPath)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, content := range []*string{existing, &ctx.Output} {
+		if content == nil {
+			continue
+		}
+		if match := copyrightYears.FindStringSubmatch(headerText(*content)); match != nil {
+			year, _ := strconv.Atoi(match[1])
+			return year, nil
+		}
+	}
+	return 0, nil
+}
+
+// headerText returns the first lines of content, where headers live.
+func headerText(content string) string {
+	const maxHeaderLines = 40
+
+	lines := strings.SplitN(content, "\n", maxHeaderLines+1)
+	return strings.Join(lines[:min(len(lines), maxHeaderLines)], "\n")
+}
@@ -0,0 +1,69 @@
+package templatehooks_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/templatehooks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLicenseNotices(t *testing.T) {
+	require.Equal(t, []string{"Apache-2.0", "BSD-3-Clause", "MIT", "MPL-2.0"}, templatehooks.LicenseNotices())
+
+	notice, ok := templatehooks.LicenseNotice("MPL-2.0")
+	require.True(t, ok)
+	require.Contains(t, notice, "https://mozilla.org/MPL/2.0/")
+
+	_, ok = templatehooks.LicenseNotice("GPL-3.0-only")
+	require.False(t, ok)
+}
+
+func TestCommonHooks_AddSPDXHeaderHook(t *testing.T) {
+	hooks := templatehooks.NewCommonHooks()
+
+	out := runHook(t, hooks.AddSPDXHeaderHook("MIT",
+		templatehooks.WithSPDXHolder("ACME Inc."),
+		templatehooks.WithSPDXYear(2025),
+	), template.HookContext{TemplateName: "main.go", Output: "package main\n"})
+	require.Equal(t, "// SPDX-License-Identifier: MIT\n// Copyright (c) 2025 ACME Inc.\n\npackage main\n", out)
+
+	out = runHook(t, hooks.AddSPDXHeaderHook("MPL-2.0", templatehooks.WithSPDXNotice(true)),
+		template.HookContext{TemplateName: "tool.py", Output: "import os\n"})
+	require.Equal(t, "# SPDX-License-Identifier: MPL-2.0\n#\n"+
+		"# This Source Code Form is subject to the terms of the Mozilla Public\n"+
+		"# License, v. 2.0. If a copy of the MPL was not distributed with this\n"+
+		"# file, You can obtain one at https://mozilla.org/MPL/2.0/.\n\nimport os\n", out)
+}
+
+func TestCommonHooks_AddSPDXHeaderHook_YearRange(t *testing.T) {
+	hooks := templatehooks.NewCommonHooks()
+	fsys := fstest.MapFS{
+		"pkg/model.go": {Data: []byte("// SPDX-License-Identifier: Apache-2.0\n// Copyright (c) 2021-2024 ACME Inc.\n\npackage pkg\n")},
+	}
+
+	hook := hooks.AddSPDXHeaderHook("Apache-2.0",
+		templatehooks.WithSPDXHolder("ACME Inc."),
+		templatehooks.WithSPDXYear(2026),
+		templatehooks.WithSPDXFS(fsys),
+	)
+
+	ctx := template.HookContext{TemplateName: "model.go.tpl", OutputPath: "pkg/model.go", Output: "package pkg\n"}
+	out := runHook(t, hook, ctx)
+	require.Equal(t, "// SPDX-License-Identifier: Apache-2.0\n// Copyright (c) 2021-2026 ACME Inc.\n\npackage pkg\n", out)
+
+	// The template already carries an older header: it is updated, not duplicated.
+	ctx.Output = "// SPDX-License-Identifier: Apache-2.0\n// Copyright (c) 2023 ACME Inc.\n\npackage pkg\n"
+	ctx.OutputPath = "pkg/new.go"
+	out = runHook(t, hook, ctx)
+	require.Equal(t, "// SPDX-License-Identifier: Apache-2.0\n// Copyright (c) 2023-2026 ACME Inc.\n\npackage pkg\n", out)
+}
+
+func TestCommonHooks_AddSPDXHeaderHook_UnknownNotice(t *testing.T) {
+	hooks := templatehooks.NewCommonHooks()
+
+	ctx := &template.HookContext{TemplateName: "main.go", Output: "package main\n"}
+	_, err := hooks.AddSPDXHeaderHook("GPL-3.0-only", templatehooks.WithSPDXNotice(true))(ctx)
+	require.ErrorContains(t, err, `no bundled notice for license "GPL-3.0-only"`)
+}
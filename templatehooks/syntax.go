@@ -0,0 +1,282 @@
+package templatehooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/goliatone/go-template"
+	"gopkg.in/yaml.v3"
+)
+
+// SyntaxHookName identifies ValidateSyntaxHook in *template.HookError values.
+const SyntaxHookName = "syntax"
+
+// SyntaxFormat is a structured output format checked by ValidateSyntaxHook.
+type SyntaxFormat string
+
+const (
+	SyntaxNone SyntaxFormat = ""
+	SyntaxJSON SyntaxFormat = "json"
+	SyntaxYAML SyntaxFormat = "yaml"
+	SyntaxTOML SyntaxFormat = "toml"
+	SyntaxXML  SyntaxFormat = "xml"
+)
+
+var syntaxExtensions = map[string]SyntaxFormat{
+	".json": SyntaxJSON,
+	".yaml": SyntaxYAML,
+	".yml":  SyntaxYAML,
+	".toml": SyntaxTOML,
+	".xml":  SyntaxXML,
+	".svg":  SyntaxXML,
+}
+
+// DetectSyntaxFormat returns the format of a file name from its extension. A
+// trailing unknown extension, such as a template extension, is ignored, so
+// "config.yaml.tpl" is YAML.
+func DetectSyntaxFormat(name string) SyntaxFormat {
+	ext := strings.ToLower(path.Ext(name))
+	if format, ok := syntaxExtensions[ext]; ok {
+		return format
+	}
+	if ext == "" {
+		return SyntaxNone
+	}
+	return syntaxExtensions[strings.ToLower(path.Ext(strings.TrimSuffix(name, path.Ext(name))))]
+}
+
+// SyntaxHookOption configures ValidateSyntaxHook behaviour.
+type SyntaxHookOption func(*SyntaxHookConfig)
+
+// SyntaxHookConfig captures settings for ValidateSyntaxHook.
+type SyntaxHookConfig struct {
+	Format    SyntaxFormat
+	Reformat  bool
+	Indent    int
+	Condition template.HookCondition
+}
+
+// WithSyntaxFormat checks every output as format instead of detecting it.
+func WithSyntaxFormat(format SyntaxFormat) SyntaxHookOption {
+	return func(cfg *SyntaxHookConfig) {
+		cfg.Format = format
+	}
+}
+
+// WithSyntaxReformat rewrites valid JSON pretty-printed and valid YAML with
+// normalised indentation. Key order and YAML comments are kept. TOML and XML
+// outputs are returned unchanged.
+func WithSyntaxReformat(enabled bool) SyntaxHookOption {
+	return func(cfg *SyntaxHookConfig) {
+		cfg.Reformat = enabled
+	}
+}
+
+// WithSyntaxIndent sets the indentation width used when reformatting, 2 by
+// default.
+func WithSyntaxIndent(spaces int) SyntaxHookOption {
+	return func(cfg *SyntaxHookConfig) {
+		cfg.Indent = spaces
+	}
+}
+
+// WithSyntaxCondition sets a predicate governing when the hook executes.
+func WithSyntaxCondition(condition template.HookCondition) SyntaxHookOption {
+	return func(cfg *SyntaxHookConfig) {
+		cfg.Condition = condition
+	}
+}
+
+// ValidateSyntaxHook returns a post hook that parses JSON, YAML, TOML and XML
+// outputs, detected from ctx.OutputPath or else ctx.TemplateName, and fails
+// with a *template.HookError carrying the line and, when the parser reports
+// it, the column of the syntax error. Other outputs pass through unchanged.
+func (h *CommonHooks) ValidateSyntaxHook(opts ...SyntaxHookOption) template.PostHook {
+	cfg := SyntaxHookConfig{Indent: 2}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(ctx *template.HookContext) (string, error) {
+		if cfg.Condition != nil && !cfg.Condition(ctx) {
+			return ctx.Output, nil
+		}
+
+		format := cfg.Format
+		if format == SyntaxNone && ctx.OutputPath != "" {
+			format = DetectSyntaxFormat(ctx.OutputPath)
+		}
+		if format == SyntaxNone {
+			format = DetectSyntaxFormat(ctx.TemplateName)
+		}
+
+		var line, column int
+		var err error
+		output := ctx.Output
+
+		switch format {
+		case SyntaxJSON:
+			line, column, err = checkJSON(ctx.Output)
+			if err == nil && cfg.Reformat {
+				output, err = reformatJSON(ctx.Output, cfg.Indent)
+			}
+		case SyntaxYAML:
+			line, err = checkYAML(ctx.Output)
+			if err == nil && cfg.Reformat {
+				output, err = reformatYAML(ctx.Output, cfg.Indent)
+			}
+		case SyntaxTOML:
+			line, column, err = checkTOML(ctx.Output)
+		case SyntaxXML:
+			line, column, err = checkXML(ctx.Output)
+		default:
+			return ctx.Output, nil
+		}
+
+		if err != nil {
+			return "", &template.HookError{
+				Hook:         SyntaxHookName,
+				TemplateName: ctx.TemplateName,
+				Line:         line,
+				Column:       column,
+				Err:          fmt.Errorf("invalid %s: %w", format, err),
+			}
+		}
+
+		return output, nil
+	}
+}
+
+// position converts a byte offset into a 1-based line and column.
+func position(src string, offset int64) (int, int) {
+	offset = max(0, min(offset, int64(len(src))))
+	before := src[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := len(before) - strings.LastIndex(before, "\n")
+	return line, column
+}
+
+func checkJSON(src string) (int, int, error) {
+	dec := json.NewDecoder(strings.NewReader(src))
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// Offset counts the offending byte.
+			line, column := position(src, syntaxErr.Offset-1)
+			return line, column, err
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			line, column := position(src, int64(len(src)))
+			return line, column, errors.New("unexpected end of input")
+		}
+		return 0, 0, err
+	}
+
+	rest := src[dec.InputOffset():]
+	if trimmed := strings.TrimLeft(rest, " \t\r\n"); trimmed != "" {
+		line, column := position(src, int64(len(src)-len(trimmed)))
+		return line, column, errors.New("unexpected data after top-level value")
+	}
+
+	return 0, 0, nil
+}
+
+func reformatJSON(src string, indent int) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(src)), "", strings.Repeat(" ", indent)); err != nil {
+		return "", err
+	}
+	buf.WriteByte('\n')
+	return buf.String(), nil
+}
+
+var yamlLine = regexp.MustCompile(`line (\d+)`)
+
+func checkYAML(src string) (int, error) {
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		if err != nil {
+			line := 0
+			if match := yamlLine.FindStringSubmatch(err.Error()); match != nil {
+				line, _ = strconv.Atoi(match[1])
+			}
+			return line, errors.New(strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+	}
+}
+
+func reformatYAML(src string, indent int) (string, error) {
+	dec := yaml.NewDecoder(strings.NewReader(src))
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := enc.Encode(&node); err != nil {
+			return "", err
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func checkTOML(src string) (int, int, error) {
+	var v map[string]any
+	_, err := toml.Decode(src, &v)
+
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.Position.Line, parseErr.Position.Col, errors.New(parseErr.Message)
+	}
+	return 0, 0, err
+}
+
+func checkXML(src string) (int, int, error) {
+	dec := xml.NewDecoder(strings.NewReader(src))
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return 0, 0, nil
+		}
+		if err != nil {
+			// InputPos points past the last byte read, which caused the error.
+			line, column := dec.InputPos()
+			if column > 1 {
+				column--
+			}
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return syntaxErr.Line, column, errors.New(syntaxErr.Msg)
+			}
+			return line, column, err
+		}
+	}
+}
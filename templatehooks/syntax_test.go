@@ -0,0 +1,84 @@
+package templatehooks_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goliatone/go-template"
+	"github.com/goliatone/go-template/templatehooks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSyntaxHook_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		output  string
+		line    int
+		column  int
+		message string
+	}{
+		{name: "json", path: "config.json", output: "{\n  \"a\": 1,\n  \"b\" 2\n}\n", line: 3, column: 7, message: "invalid json: invalid character '2'"},
+		{name: "json trailing", path: "config.json", output: "{}\n{}\n", line: 2, column: 1, message: "unexpected data after top-level value"},
+		{name: "yaml", path: "config.yaml", output: "name: api\ndescription: uses: colons\n", line: 2, message: "invalid yaml: line 2: mapping values are not allowed"},
+		{name: "toml", path: "Cargo.toml", output: "[package]\nname = \"x\"\nversion = \n", line: 3, column: 11, message: "invalid toml:"},
+		{name: "xml", path: "pom.xml", output: "<project>\n  <name>x</nam>\n</project>\n", line: 2, column: 15, message: "invalid xml: element <name> closed by </nam>"},
+	}
+
+	hook := templatehooks.NewCommonHooks().ValidateSyntaxHook()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := hook(&template.HookContext{TemplateName: tt.path + ".tpl", OutputPath: tt.path, Output: tt.output})
+
+			var hookErr *template.HookError
+			require.True(t, errors.As(err, &hookErr), "%v", err)
+			require.Equal(t, templatehooks.SyntaxHookName, hookErr.Hook)
+			require.Equal(t, tt.line, hookErr.Line)
+			require.Equal(t, tt.column, hookErr.Column)
+			require.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestValidateSyntaxHook_ValidAndUnknown(t *testing.T) {
+	hook := templatehooks.NewCommonHooks().ValidateSyntaxHook()
+
+	valid := map[string]string{
+		"a.json":   "{\"a\": [1, 2]}\n",
+		"a.yml":    "---\na: 1\n---\nb: 2\n",
+		"a.toml":   "a = 1\n",
+		"a.svg":    "<?xml version=\"1.0\"?>\n<svg></svg>\n",
+		"main.go":  "not { checked",
+		"Makefile": "all:\n",
+	}
+	for name, output := range valid {
+		out, err := hook(&template.HookContext{OutputPath: name, Output: output})
+		require.NoError(t, err, name)
+		require.Equal(t, output, out, name)
+	}
+
+	// Without an output path the template name decides.
+	_, err := hook(&template.HookContext{TemplateName: "config.json.tpl", Output: "{"})
+	require.ErrorContains(t, err, "unexpected end of input")
+}
+
+func TestValidateSyntaxHook_Reformat(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithBaseDir("../testdata"))
+	require.NoError(t, err)
+
+	hooks := templatehooks.NewCommonHooks()
+	renderer.RegisterPostHook(hooks.ValidateSyntaxHook(
+		templatehooks.WithSyntaxFormat(templatehooks.SyntaxJSON),
+		templatehooks.WithSyntaxReformat(true),
+	))
+
+	out, err := renderer.RenderString(`{"name": "{{ name }}", "tags": ["a","b"]}`, map[string]any{"name": "api"})
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"name\": \"api\",\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ]\n}\n", out)
+
+	yamlHook := hooks.ValidateSyntaxHook(templatehooks.WithSyntaxReformat(true))
+	out, err = yamlHook(&template.HookContext{OutputPath: "config.yaml", Output: "# service\nserver:\n      port: 80   # http\n      hosts:\n          - a\n"})
+	require.NoError(t, err)
+	require.Equal(t, "# service\nserver:\n  port: 80 # http\n  hosts:\n    - a\n", out)
+}
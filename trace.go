@@ -0,0 +1,160 @@
+package template
+
+import (
+	"io"
+	"maps"
+	"reflect"
+	"time"
+)
+
+// MetadataHookTraceKey is the HookContext.Metadata key holding the
+// *RenderTrace of a traced render. Hooks find in it the hooks that ran before
+// them.
+const MetadataHookTraceKey = "hook_trace"
+
+// HookTrace records a single pre or post hook run.
+type HookTrace struct {
+	// Name is the hook name, or for unnamed hooks its kind and position,
+	// e.g. "post hook #2".
+	Name     string
+	Kind     HookKind
+	Duration time.Duration
+	// DataChanged reports whether the hook replaced ctx.Data or, for map
+	// data, changed its top level entries. Changes nested deeper in the data
+	// are not detected.
+	DataChanged         bool
+	TemplateNameChanged bool
+	OutputChanged       bool
+	Err                 error
+}
+
+// RenderTrace records the hooks run by a render, in execution order. Hooks
+// skipped because their HookScope does not match are not recorded. It is
+// returned by RenderTemplateTrace and RenderStringTrace.
+type RenderTrace struct {
+	// TemplateName is the template name once pre hooks ran, empty for
+	// RenderString.
+	TemplateName string
+	Hooks        []HookTrace
+	// Duration is the duration of the whole render.
+	Duration time.Duration
+}
+
+// HookDuration returns the time spent in hooks.
+func (t *RenderTrace) HookDuration() time.Duration {
+	var total time.Duration
+	for _, hook := range t.Hooks {
+		total += hook.Duration
+	}
+	return total
+}
+
+// traceFrom returns the trace of a traced render, nil otherwise.
+func traceFrom(meta map[string]any) *RenderTrace {
+	trace, _ := meta[MetadataHookTraceKey].(*RenderTrace)
+	return trace
+}
+
+// hookRun is a hook run in progress, started by RenderTrace.start.
+type hookRun struct {
+	trace  *RenderTrace
+	entry  HookTrace
+	start  time.Time
+	data   any
+	fields map[string]any
+	name   string
+	output string
+}
+
+// start records the state of ctx before a hook runs. It is a no-op on a nil
+// trace.
+func (t *RenderTrace) start(name string, kind HookKind, ctx *HookContext) *hookRun {
+	if t == nil {
+		return nil
+	}
+
+	run := &hookRun{
+		trace:  t,
+		entry:  HookTrace{Name: name, Kind: kind},
+		data:   ctx.Data,
+		name:   ctx.TemplateName,
+		output: ctx.Output,
+	}
+	if m, ok := ctx.Data.(map[string]any); ok {
+		run.fields = maps.Clone(m)
+	}
+	run.start = time.Now()
+	return run
+}
+
+// end records the hook run once the hook returned output and err.
+func (run *hookRun) end(ctx *HookContext, output string, err error) {
+	if run == nil {
+		return
+	}
+
+	run.entry.Duration = time.Since(run.start)
+	run.entry.Err = err
+	run.entry.TemplateNameChanged = ctx.TemplateName != run.name
+	run.entry.OutputChanged = err == nil && !ctx.IsPreHook && output != run.output
+	run.entry.DataChanged = run.dataChanged(ctx.Data)
+
+	run.trace.Hooks = append(run.trace.Hooks, run.entry)
+	run.trace.TemplateName = ctx.TemplateName
+}
+
+func (run *hookRun) dataChanged(data any) bool {
+	m, ok := data.(map[string]any)
+	if !ok || run.fields == nil {
+		return !sameValue(run.data, data)
+	}
+
+	if len(m) != len(run.fields) {
+		return true
+	}
+	for key, before := range run.fields {
+		after, ok := m[key]
+		if !ok || !sameValue(before, after) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameValue reports whether a and b hold the same value, comparing
+// references by identity so data holding functions or large collections can
+// be compared cheaply.
+func sameValue(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return va.IsValid() == vb.IsValid()
+	}
+	if va.Type() != vb.Type() {
+		return false
+	}
+
+	switch va.Kind() {
+	case reflect.Map, reflect.Slice:
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+	case reflect.Func, reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return va.Pointer() == vb.Pointer()
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// RenderTemplateTrace renders like RenderTemplate and also returns a trace of
+// the pre and post hooks that ran. The trace is returned when the render
+// fails too, recording the hooks up to the failing one.
+func (r *Engine) RenderTemplateTrace(name string, data any, out ...io.Writer) (string, *RenderTrace, error) {
+	req := &fileRequest{name: name, data: data, trace: &RenderTrace{TemplateName: name}}
+	output, err := r.renderFile(req, out...)
+	return output, req.trace, err
+}
+
+// RenderStringTrace renders like RenderString and also returns a trace of the
+// pre and post hooks that ran.
+func (r *Engine) RenderStringTrace(templateContent string, data any, out ...io.Writer) (string, *RenderTrace, error) {
+	trace := &RenderTrace{}
+	output, err := r.renderString(templateContent, data, trace, out)
+	return output, trace, err
+}
@@ -0,0 +1,126 @@
+package template_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_RenderTemplateTrace(t *testing.T) {
+	fsys := fstest.MapFS{
+		"v1/page.tpl": {Data: []byte("Hello {{ name }}")},
+		"v2/page.tpl": {Data: []byte("Hi {{ name }}")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	require.NoError(t, renderer.RegisterNamedPreHook("defaults", func(ctx *template.HookContext) error {
+		data := ctx.Data.(map[string]any)
+		if _, ok := data["name"]; !ok {
+			data["name"] = "World"
+		}
+		return nil
+	}))
+	require.NoError(t, renderer.RegisterNamedPreHook("versioned", func(ctx *template.HookContext) error {
+		ctx.TemplateName = strings.Replace(ctx.TemplateName, "v1/", "v2/", 1)
+		return nil
+	}))
+	renderer.RegisterPostHook(func(ctx *template.HookContext) (string, error) {
+		return ctx.Output, nil
+	})
+	require.NoError(t, renderer.RegisterNamedPostHook("shout", func(ctx *template.HookContext) (string, error) {
+		trace := ctx.Metadata[template.MetadataHookTraceKey].(*template.RenderTrace)
+		require.Len(t, trace.Hooks, 3, "earlier hooks are visible to later ones")
+		return strings.ToUpper(ctx.Output), nil
+	}))
+
+	output, trace, err := renderer.RenderTemplateTrace("v1/page", map[string]any{})
+	require.NoError(t, err)
+	require.Equal(t, "HI WORLD", output)
+
+	require.Equal(t, "v2/page", trace.TemplateName)
+	require.Len(t, trace.Hooks, 4)
+
+	changes := func(h template.HookTrace) [3]bool {
+		return [3]bool{h.DataChanged, h.TemplateNameChanged, h.OutputChanged}
+	}
+	require.Equal(t, "defaults", trace.Hooks[0].Name)
+	require.Equal(t, template.HookKindPre, trace.Hooks[0].Kind)
+	require.Equal(t, [3]bool{true, false, false}, changes(trace.Hooks[0]))
+	require.Equal(t, "versioned", trace.Hooks[1].Name)
+	require.Equal(t, [3]bool{false, true, false}, changes(trace.Hooks[1]))
+	require.Equal(t, "post hook #1", trace.Hooks[2].Name)
+	require.Equal(t, [3]bool{false, false, false}, changes(trace.Hooks[2]))
+	require.Equal(t, "shout", trace.Hooks[3].Name)
+	require.Equal(t, template.HookKindPost, trace.Hooks[3].Kind)
+	require.Equal(t, [3]bool{false, false, true}, changes(trace.Hooks[3]))
+
+	require.Positive(t, trace.Duration)
+	require.LessOrEqual(t, trace.HookDuration(), trace.Duration)
+}
+
+func TestEngine_RenderStringTraceFailure(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{}))
+	require.NoError(t, err)
+
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		ctx.Data = map[string]any{"replaced": true}
+		return nil
+	})
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		return errors.New("invalid")
+	})
+
+	_, trace, err := renderer.RenderStringTrace("text", map[string]any{"replaced": false})
+	require.Error(t, err)
+	require.NotNil(t, trace)
+	require.Len(t, trace.Hooks, 2)
+	require.True(t, trace.Hooks[0].DataChanged)
+	require.EqualError(t, trace.Hooks[1].Err, "invalid")
+}
+
+func TestEngine_UntracedRenderHasNoTrace(t *testing.T) {
+	renderer, err := template.NewRenderer(template.WithFS(fstest.MapFS{}))
+	require.NoError(t, err)
+
+	renderer.RegisterPostHook(func(ctx *template.HookContext) (string, error) {
+		require.NotContains(t, ctx.Metadata, template.MetadataHookTraceKey)
+		return ctx.Output, nil
+	})
+
+	_, err = renderer.RenderString("text", nil)
+	require.NoError(t, err)
+}
+
+func TestEngine_RenderTraceSkipsScopedOutHooks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"emails/welcome.tpl": {Data: []byte("welcome")},
+		"pages/home.tpl":     {Data: []byte("home")},
+	}
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	require.NoError(t, renderer.RegisterNamedPostHook("email-footer", func(ctx *template.HookContext) (string, error) {
+		return ctx.Output + "\n-- the team", nil
+	}, template.WithHookNamespaces("emails")))
+	require.NoError(t, renderer.RegisterNamedPreHook("email-defaults", func(ctx *template.HookContext) error {
+		return nil
+	}, template.WithHookNamespaces("emails")))
+
+	output, trace, err := renderer.RenderTemplateTrace("pages/home", nil)
+	require.NoError(t, err)
+	require.Equal(t, "home", output)
+	require.Empty(t, trace.Hooks)
+
+	output, trace, err = renderer.RenderTemplateTrace("emails/welcome", nil)
+	require.NoError(t, err)
+	require.Equal(t, "welcome\n-- the team", output)
+	require.Len(t, trace.Hooks, 2)
+	require.Equal(t, "email-defaults", trace.Hooks[0].Name)
+	require.Equal(t, "email-footer", trace.Hooks[1].Name)
+	require.True(t, trace.Hooks[1].OutputChanged)
+}
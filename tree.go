@@ -0,0 +1,396 @@
+package template
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// GeneratedFile is a single output file produced by GenerateTree or RenderFile.
+type GeneratedFile struct {
+	// Path is the slash separated output path, relative to the output root.
+	Path string
+	// Source is the path of the template or asset in the engine file system.
+	Source string
+	// Content holds the rendered output, or the raw bytes for assets.
+	Content []byte
+	// Mode holds the permission bits of the source file.
+	Mode fs.FileMode
+	// IsTemplate is false for assets copied verbatim.
+	IsTemplate bool
+	// Policy decides whether the file may replace an existing file when
+	// written. Empty means WriteAlways unless the writer sets a default.
+	Policy WritePolicy
+}
+
+// TreeOption configures GenerateTree and Scaffold.
+type TreeOption func(*treeConfig)
+
+type treeConfig struct {
+	exclude        []string
+	skipAssets     bool
+	renderPaths    bool
+	skipEmptyPaths bool
+}
+
+// WithTreeExclude skips source files or directories whose path relative to the
+// tree root, or base name, matches any of the given path.Match patterns.
+func WithTreeExclude(patterns ...string) TreeOption {
+	return func(c *treeConfig) {
+		c.exclude = append(c.exclude, patterns...)
+	}
+}
+
+// WithTreeAssets controls whether non template files are copied verbatim. It
+// is enabled by default.
+func WithTreeAssets(enabled bool) TreeOption {
+	return func(c *treeConfig) {
+		c.skipAssets = !enabled
+	}
+}
+
+// WithTreePathTemplates controls whether path segments are rendered as
+// templates. It is enabled by default.
+func WithTreePathTemplates(enabled bool) TreeOption {
+	return func(c *treeConfig) {
+		c.renderPaths = enabled
+	}
+}
+
+// WithTreeSkipEmptyPaths skips files with a path segment rendering to an
+// empty string, e.g. "{% if with_docker %}Dockerfile{% endif %}.tpl", instead
+// of failing. Templates can opt in on their own with the `skip_empty_path`
+// front matter key.
+func WithTreeSkipEmptyPaths(enabled bool) TreeOption {
+	return func(c *treeConfig) {
+		c.skipEmptyPaths = enabled
+	}
+}
+
+// GenerateTree walks the directory `root` in the engine file system and renders
+// every file in memory:
+//
+//   - path segments are rendered as templates, e.g. "{{ package_name }}/model.go.tpl";
+//     a segment rendering to an empty string is an error unless skipping is
+//     enabled, see WithTreeSkipEmptyPaths
+//   - files ending with the template extension are rendered through the
+//     regular pre/post hooks, with HookContext.OutputPath set, and the
+//     extension is stripped from the output path
+//   - template paths are rendered against the data the body sees, once pre
+//     hooks and front matter defaults applied, so pre hooks see the path
+//     unrendered and post hooks see it rendered
+//   - the front matter `output` key, when present, replaces the output path,
+//     and `write_policy` sets GeneratedFile.Policy
+//   - any other file is copied verbatim
+//
+// Output paths are relative to the output root and slash separated.
+func (r *Engine) GenerateTree(root string, data any, opts ...TreeOption) ([]GeneratedFile, error) {
+	cfg := treeConfig{renderPaths: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	fsys, err := r.SourceFS()
+	if err != nil {
+		return nil, err
+	}
+
+	root = path.Clean(filepath.ToSlash(root))
+
+	pathContext, err := ConvertToContext(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert data to context: %w", err)
+	}
+
+	var files []GeneratedFile
+	seen := make(map[string]string)
+
+	err = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		if root == "." {
+			rel = p
+		}
+		if rel == "" || rel == "." {
+			return nil
+		}
+
+		if cfg.excluded(rel) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		isTemplate := strings.HasSuffix(rel, r.tplExt)
+		if !isTemplate && cfg.skipAssets {
+			return nil
+		}
+
+		outPath := rel
+		if isTemplate {
+			outPath = strings.TrimSuffix(rel, r.tplExt)
+		}
+
+		if cfg.renderPaths && !isTemplate {
+			rendered, empty, err := r.renderPath(outPath, pathContext)
+			if err != nil {
+				return fmt.Errorf("failed to render path %s: %w", p, err)
+			}
+			if empty {
+				if cfg.skipEmptyPaths {
+					return nil
+				}
+				return fmt.Errorf("path %s has a segment rendering empty", p)
+			}
+			outPath = rendered
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		file := GeneratedFile{
+			Source:     p,
+			Mode:       info.Mode().Perm(),
+			IsTemplate: isTemplate,
+		}
+
+		if isTemplate {
+			meta, err := r.Metadata(p)
+			if err != nil {
+				return err
+			}
+			if meta != nil {
+				file.Policy = meta.WritePolicy
+			}
+			req := &fileRequest{
+				name:       p,
+				data:       data,
+				outputPath: outPath,
+				renderPath: cfg.renderPaths,
+				skipEmpty:  cfg.skipEmptyPaths,
+			}
+			if meta != nil && meta.Output != "" {
+				req.outputPath, req.renderPath = meta.Output, true
+			}
+
+			output, err := r.renderFile(req)
+			if err != nil {
+				return err
+			}
+			if req.skipped {
+				return nil
+			}
+			outPath = req.outputPath
+			file.Content = []byte(output)
+		} else {
+			content, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			file.Content = content
+		}
+
+		outPath, err = CleanOutputPath(outPath)
+		if err != nil {
+			return fmt.Errorf("invalid output path for %s: %w", p, err)
+		}
+
+		if prev, ok := seen[outPath]; ok {
+			return fmt.Errorf("output path %s produced by both %s and %s", outPath, prev, p)
+		}
+		seen[outPath] = p
+
+		file.Path = outPath
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// FileOption configures RenderFile.
+type FileOption func(*fileRequest)
+
+// WithOutputPath sets HookContext.OutputPath for the render.
+func WithOutputPath(p string) FileOption {
+	return func(req *fileRequest) {
+		req.outputPath = p
+	}
+}
+
+// WithFilePreHooks adds pre hooks that run after the engine pre hooks for this
+// render only.
+func WithFilePreHooks(hooks ...PreHook) FileOption {
+	return func(req *fileRequest) {
+		req.preHooks = append(req.preHooks, hooks...)
+	}
+}
+
+// WithFilePostHooks adds post hooks that run after the engine post hooks for
+// this render only.
+func WithFilePostHooks(hooks ...PostHook) FileOption {
+	return func(req *fileRequest) {
+		req.postHooks = append(req.postHooks, hooks...)
+	}
+}
+
+// RenderFile renders the template `name` as a file destined to an output path.
+// It behaves like RenderTemplate but exposes the output path to hooks, accepts
+// per render hooks and reports the output path after hooks ran.
+func (r *Engine) RenderFile(name string, data any, opts ...FileOption) (GeneratedFile, error) {
+	req := &fileRequest{name: name, data: data}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	output, err := r.renderFile(req)
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+
+	return GeneratedFile{
+		Path:       req.outputPath,
+		Source:     r.templatePath(req.name),
+		Content:    []byte(output),
+		IsTemplate: true,
+	}, nil
+}
+
+// Expand renders a short inline template, such as a path or a condition,
+// against data. Unlike RenderString no hooks run, no front matter is parsed and
+// the output is not HTML escaped.
+func (r *Engine) Expand(text string, data any) (string, error) {
+	if !isTemplateContent(text) {
+		return text, nil
+	}
+
+	ctx, err := ConvertToContext(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert data to context: %w", err)
+	}
+
+	tmpl, err := r.fromInline(text)
+	if err != nil {
+		return "", err
+	}
+
+	return tmpl.Execute(ctx)
+}
+
+// fromInline compiles an inline template with autoescape off: paths and
+// conditions are not HTML, and escaping would turn "a&b" into "a&amp;b".
+func (r *Engine) fromInline(text string) (*pongo2.Template, error) {
+	return r.templateSet.FromString("{% autoescape off %}" + text + "{% endautoescape %}")
+}
+
+// SourceFS returns the file system templates are read from, giving the base
+// directory precedence like the template loaders do.
+func (r *Engine) SourceFS() (fs.FS, error) {
+	if r.baseDir != "" {
+		return os.DirFS(r.baseDir), nil
+	}
+	if r.fs != nil {
+		return r.fs, nil
+	}
+	return nil, fmt.Errorf("need to provide either baseDir or fs.FS")
+}
+
+// Scaffold renders the tree at `root` with GenerateTree and writes the result
+// under the `dest` directory on disk, preserving source permissions.
+func (r *Engine) Scaffold(root, dest string, data any, opts ...TreeOption) ([]GeneratedFile, error) {
+	files, err := r.GenerateTree(root, data, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := WriteFiles(dest, files); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// WriteFiles writes generated files under dest, creating directories as needed.
+// Files are written atomically as a whole, see WriteFilesAtomic.
+func WriteFiles(dest string, files []GeneratedFile) error {
+	_, err := WriteFilesAtomic(dest, files)
+	return err
+}
+
+func (c *treeConfig) excluded(rel string) bool {
+	base := path.Base(rel)
+	for _, pattern := range c.exclude {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// renderPath renders every segment of p as a template. empty reports that a
+// segment rendered empty.
+func (r *Engine) renderPath(p string, ctx map[string]any) (string, bool, error) {
+	if !isTemplateContent(p) {
+		return p, false, nil
+	}
+
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		if !isTemplateContent(segment) {
+			continue
+		}
+
+		tmpl, err := r.fromInline(segment)
+		if err != nil {
+			return "", false, err
+		}
+
+		rendered, err := tmpl.Execute(ctx)
+		if err != nil {
+			return "", false, err
+		}
+
+		rendered = strings.TrimSpace(rendered)
+		if rendered == "" {
+			return "", true, nil
+		}
+		segments[i] = rendered
+	}
+
+	return strings.Join(segments, "/"), false, nil
+}
+
+// CleanOutputPath cleans the output path of a generated file into the slash
+// separated form of GeneratedFile.Path. It rejects empty paths, "." and paths
+// escaping the output directory.
+func CleanOutputPath(p string) (string, error) {
+	p = path.Clean(filepath.ToSlash(strings.TrimSpace(p)))
+	if p == "." || p == "" {
+		return "", fmt.Errorf("empty path")
+	}
+	if path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("%s escapes the output directory", p)
+	}
+	return p, nil
+}
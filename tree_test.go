@@ -0,0 +1,203 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_GenerateTree(t *testing.T) {
+	fsys := fstest.MapFS{
+		"project/{{ package_name }}/model.go.tpl":               {Data: []byte("package {{ package_name }}\n"), Mode: 0o644},
+		"project/README.md.tpl":                                 {Data: []byte("# {{ name }}\n")},
+		"project/scripts/run.sh":                                {Data: []byte("#!/bin/sh\necho {{ not rendered }}\n"), Mode: 0o755},
+		"project/{% if with_docker %}Dockerfile{% endif %}.tpl": {Data: []byte("---\nskip_empty_path: true\n---\nFROM golang\n")},
+		"project/docs/.keep":                                    {Data: []byte("")},
+		"project/config.tpl":                                    {Data: []byte("---\noutput: \"config/{{ name }}.yaml\"\nwrite_policy: create-only\n---\nname: {{ name }}\n")},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	var seen []string
+	renderer.RegisterPostHook(func(ctx *template.HookContext) (string, error) {
+		seen = append(seen, ctx.OutputPath)
+		return ctx.Output, nil
+	})
+
+	files, err := renderer.GenerateTree("project", map[string]any{
+		"package_name": "models",
+		"name":         "demo",
+		"with_docker":  false,
+	}, template.WithTreeExclude(".keep"))
+	require.NoError(t, err)
+
+	byPath := map[string]template.GeneratedFile{}
+	for _, f := range files {
+		byPath[f.Path] = f
+	}
+
+	require.Len(t, byPath, 4)
+	require.Equal(t, "package models\n", string(byPath["models/model.go"].Content))
+	require.True(t, byPath["models/model.go"].IsTemplate)
+	require.Equal(t, "project/{{ package_name }}/model.go.tpl", byPath["models/model.go"].Source)
+	require.Equal(t, "# demo\n", string(byPath["README.md"].Content))
+	require.Equal(t, "name: demo\n", string(byPath["config/demo.yaml"].Content))
+	require.Equal(t, template.WriteCreateOnly, byPath["config/demo.yaml"].Policy)
+	require.Empty(t, byPath["README.md"].Policy)
+
+	script := byPath["scripts/run.sh"]
+	require.False(t, script.IsTemplate)
+	require.Equal(t, "#!/bin/sh\necho {{ not rendered }}\n", string(script.Content))
+	require.Equal(t, os.FileMode(0o755), script.Mode)
+
+	require.ElementsMatch(t, []string{"models/model.go", "README.md", "config/demo.yaml"}, seen)
+}
+
+func TestEngine_GenerateTree_Options(t *testing.T) {
+	fsys := fstest.MapFS{
+		"tree/a.txt.tpl":   {Data: []byte("A")},
+		"tree/b.bin":       {Data: []byte{0, 1}},
+		"tree/{{ x }}.tpl": {Data: []byte("X")},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	files, err := renderer.GenerateTree("tree", map[string]any{"x": "ignored"},
+		template.WithTreeAssets(false),
+		template.WithTreePathTemplates(false),
+	)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	require.Equal(t, []string{"a.txt", "{{ x }}"}, paths)
+}
+
+func TestEngine_GenerateTree_PathsAreNotEscaped(t *testing.T) {
+	fsys := fstest.MapFS{
+		"tree/{{ name }}.txt.tpl": {Data: []byte("{{ name }}")},
+		"tree/data.tpl":           {Data: []byte("---\noutput: \"out/{{ name }}.json\"\n---\n{}")},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	name := `a&b 'c' "d" <e>`
+	files, err := renderer.GenerateTree("tree", map[string]any{"name": name})
+	require.NoError(t, err)
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	require.ElementsMatch(t, []string{name + ".txt", "out/" + name + ".json"}, paths)
+
+	expanded, err := renderer.Expand("{{ name }}", map[string]any{"name": "a&b"})
+	require.NoError(t, err)
+	require.Equal(t, "a&b", expanded)
+}
+
+func TestEngine_GenerateTree_PathsUsePreparedData(t *testing.T) {
+	fsys := fstest.MapFS{
+		"tree/{{ pkg }}/{{ name }}.go.tpl": {Data: []byte("---\ndefaults:\n  pkg: models\n---\npackage {{ pkg }} // {{ name }}\n")},
+		"tree/doc.tpl":                     {Data: []byte("---\noutput: \"docs/{{ pkg }}.md\"\ndefaults:\n  pkg: api\n---\n# {{ pkg }}\n")},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	var seen []string
+	renderer.RegisterPreHook(func(ctx *template.HookContext) error {
+		seen = append(seen, ctx.OutputPath)
+		ctx.Data = map[string]any{"name": "user"}
+		return nil
+	})
+
+	files, err := renderer.GenerateTree("tree", nil)
+	require.NoError(t, err)
+
+	byPath := map[string]string{}
+	for _, f := range files {
+		byPath[f.Path] = string(f.Content)
+	}
+	require.Equal(t, map[string]string{
+		"models/user.go": "package models // user\n",
+		"docs/api.md":    "# api\n",
+	}, byPath)
+	require.ElementsMatch(t, []string{"{{ pkg }}/{{ name }}.go", "docs/{{ pkg }}.md"}, seen, "pre hooks see the path unrendered")
+}
+
+func TestEngine_GenerateTree_EmptyPathSegments(t *testing.T) {
+	fsys := fstest.MapFS{
+		"tree/main.go.tpl": {Data: []byte("package main")},
+		"tree/{% if docker %}Dockerfile{% endif %}.tpl": {Data: []byte("FROM golang")},
+		"tree/{% if ci %}ci{% endif %}/build.yml":       {Data: []byte("steps: []")},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	_, err = renderer.GenerateTree("tree", map[string]any{"ci": true})
+	require.ErrorContains(t, err, "segment rendering empty")
+
+	_, err = renderer.GenerateTree("tree", map[string]any{"docker": true})
+	require.ErrorContains(t, err, "segment rendering empty")
+
+	files, err := renderer.GenerateTree("tree", map[string]any{}, template.WithTreeSkipEmptyPaths(true))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "main.go", files[0].Path)
+}
+
+func TestEngine_GenerateTree_Errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dup/a.tpl":          {Data: []byte("1")},
+		"dup/{{ name }}.tpl": {Data: []byte("2")},
+		"escape/{{ p }}.tpl": {Data: []byte("x")},
+	}
+
+	renderer, err := template.NewRenderer(template.WithFS(fsys))
+	require.NoError(t, err)
+
+	_, err = renderer.GenerateTree("dup", map[string]any{"name": "a"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "produced by both")
+
+	_, err = renderer.GenerateTree("escape", map[string]any{"p": "../../etc/passwd"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "escapes the output directory")
+
+	_, err = renderer.GenerateTree("missing", nil)
+	require.Error(t, err)
+}
+
+func TestEngine_Scaffold(t *testing.T) {
+	src := writeTemplates(t, map[string]string{
+		"service/cmd/{{ name }}/main.go.tpl": "package main // {{ name }}\n",
+		"service/static/logo.svg":            "<svg/>",
+	})
+	dest := t.TempDir()
+
+	renderer, err := template.NewRenderer(template.WithBaseDir(src))
+	require.NoError(t, err)
+
+	files, err := renderer.Scaffold("service", dest, map[string]any{"name": "api"})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	main, err := os.ReadFile(filepath.Join(dest, "cmd", "api", "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main // api\n", string(main))
+
+	logo, err := os.ReadFile(filepath.Join(dest, "static", "logo.svg"))
+	require.NoError(t, err)
+	require.Equal(t, "<svg/>", string(logo))
+}
@@ -0,0 +1,305 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// WritePolicy decides whether a generated file may replace an existing file.
+type WritePolicy string
+
+const (
+	// WriteAlways overwrites existing files. It is the default.
+	WriteAlways WritePolicy = "always"
+	// WriteCreateOnly writes the file only when it does not exist yet.
+	WriteCreateOnly WritePolicy = "create-only"
+	// WriteIfGenerated overwrites a file only when it still carries a
+	// generated code header, such as the one added by AddGeneratedWarningHook,
+	// so files taken over by hand are kept.
+	WriteIfGenerated WritePolicy = "overwrite-if-generated"
+	// WriteSkipIdentical leaves files with identical content untouched.
+	WriteSkipIdentical WritePolicy = "skip-if-identical"
+)
+
+// ParseWritePolicy validates a policy name. An empty name yields WriteAlways.
+func ParseWritePolicy(name string) (WritePolicy, error) {
+	switch p := WritePolicy(strings.ToLower(strings.TrimSpace(name))); p {
+	case "":
+		return WriteAlways, nil
+	case WriteAlways, WriteCreateOnly, WriteIfGenerated, WriteSkipIdentical:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown write policy %q", name)
+	}
+}
+
+// generatedHeader matches the Go convention for generated code headers, which
+// the default AddGeneratedWarningHook message follows.
+var generatedHeader = regexp.MustCompile(`(?m)^\W*Code generated .* DO NOT EDIT\.`)
+
+// generatedHeaderLines bounds how far into a file the header is looked for,
+// leaving room for license and shebang lines.
+const generatedHeaderLines = 30
+
+// IsGenerated reports whether content starts with a generated code header.
+func IsGenerated(content []byte) bool {
+	lines := bytes.SplitN(content, []byte("\n"), generatedHeaderLines+1)
+	if len(lines) > generatedHeaderLines {
+		lines = lines[:generatedHeaderLines]
+	}
+	return generatedHeader.Match(bytes.Join(lines, []byte("\n")))
+}
+
+// allows reports whether the policy lets content replace existing, which is
+// nil when the target does not exist, and why not.
+func (p WritePolicy) allows(existing, content []byte) (bool, string) {
+	if existing == nil {
+		return true, ""
+	}
+	switch p {
+	case WriteCreateOnly:
+		return false, "file exists"
+	case WriteIfGenerated:
+		if !IsGenerated(existing) {
+			return false, "file has no generated code header"
+		}
+	case WriteSkipIdentical:
+		if bytes.Equal(existing, content) {
+			return false, "content is identical"
+		}
+	}
+	return true, ""
+}
+
+// WriteOption configures WriteFilesAtomic.
+type WriteOption func(*writeConfig)
+
+type writeConfig struct {
+	skipUnchanged bool
+	policy        WritePolicy
+}
+
+// WithWriteSkipUnchanged leaves files whose content is already up to date
+// untouched, keeping their modification time stable, whatever their policy.
+func WithWriteSkipUnchanged(enabled bool) WriteOption {
+	return func(c *writeConfig) {
+		c.skipUnchanged = enabled
+	}
+}
+
+// WithWritePolicy sets the policy of files without GeneratedFile.Policy.
+func WithWritePolicy(policy WritePolicy) WriteOption {
+	return func(c *writeConfig) {
+		c.policy = policy
+	}
+}
+
+// WriteResult lists the paths written and skipped by WriteFilesAtomic.
+type WriteResult struct {
+	Written []string
+	// Skipped lists files left untouched because they were up to date.
+	Skipped []string
+	// Kept lists existing files their write policy did not allow to replace.
+	Kept []string
+}
+
+func (c *writeConfig) policyFor(file GeneratedFile) WritePolicy {
+	if file.Policy != "" {
+		return file.Policy
+	}
+	if c.policy != "" {
+		return c.policy
+	}
+	return WriteAlways
+}
+
+// stagedFile is a generated file written to a temp file next to its target.
+type stagedFile struct {
+	path   string
+	target string
+	temp   string
+	backup string
+	done   bool
+}
+
+// WriteFilesAtomic writes generated files under dest as a single transaction.
+// Every file is first written to a temp file in its target directory; targets
+// are then swapped in with renames. If anything fails, temp files and created
+// directories are removed and replaced files are restored, leaving dest as it
+// was. Existing files keep their permissions; new files get GeneratedFile.Mode
+// or 0644. Each file's WritePolicy decides whether an existing file may be
+// replaced.
+func WriteFilesAtomic(dest string, files []GeneratedFile, opts ...WriteOption) (WriteResult, error) {
+	var cfg writeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var result WriteResult
+	var staged []*stagedFile
+	var createdDirs []string
+
+	rollback := func() {
+		for i := len(staged) - 1; i >= 0; i-- {
+			s := staged[i]
+			if s.done {
+				_ = os.Remove(s.target)
+				if s.backup != "" {
+					_ = os.Rename(s.backup, s.target)
+				}
+			} else {
+				_ = os.Remove(s.temp)
+			}
+		}
+		for i := len(createdDirs) - 1; i >= 0; i-- {
+			_ = os.Remove(createdDirs[i])
+		}
+	}
+
+	for _, file := range files {
+		target := filepath.Join(dest, filepath.FromSlash(file.Path))
+
+		info, err := os.Stat(target)
+		exists := err == nil
+		switch {
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			rollback()
+			return WriteResult{}, fmt.Errorf("failed to stat %s: %w", file.Path, err)
+		case exists && info.IsDir():
+			rollback()
+			return WriteResult{}, fmt.Errorf("failed to write %s: target is a directory", file.Path)
+		}
+
+		policy := cfg.policyFor(file)
+		if exists && (cfg.skipUnchanged || policy != WriteAlways) {
+			current, err := os.ReadFile(target)
+			if err != nil {
+				rollback()
+				return WriteResult{}, fmt.Errorf("failed to read %s: %w", file.Path, err)
+			}
+			if bytes.Equal(current, file.Content) && (cfg.skipUnchanged || policy == WriteSkipIdentical) {
+				result.Skipped = append(result.Skipped, file.Path)
+				continue
+			}
+			if ok, _ := policy.allows(current, file.Content); !ok {
+				result.Kept = append(result.Kept, file.Path)
+				continue
+			}
+		}
+
+		dirs, err := mkdirAll(filepath.Dir(target))
+		createdDirs = append(createdDirs, dirs...)
+		if err != nil {
+			rollback()
+			return WriteResult{}, fmt.Errorf("failed to create directory for %s: %w", file.Path, err)
+		}
+
+		mode := file.Mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		if exists {
+			mode = info.Mode().Perm()
+		}
+
+		temp, err := writeTemp(target, file.Content, mode)
+		if err != nil {
+			rollback()
+			return WriteResult{}, fmt.Errorf("failed to write %s: %w", file.Path, err)
+		}
+
+		s := &stagedFile{path: file.Path, target: target, temp: temp}
+		if exists {
+			s.backup = temp + ".orig"
+		}
+		staged = append(staged, s)
+	}
+
+	for _, s := range staged {
+		if s.backup != "" {
+			if err := os.Rename(s.target, s.backup); err != nil {
+				rollback()
+				return WriteResult{}, fmt.Errorf("failed to replace %s: %w", s.path, err)
+			}
+		}
+		if err := os.Rename(s.temp, s.target); err != nil {
+			if s.backup != "" {
+				_ = os.Rename(s.backup, s.target)
+				s.backup = ""
+			}
+			rollback()
+			return WriteResult{}, fmt.Errorf("failed to replace %s: %w", s.path, err)
+		}
+		s.done = true
+	}
+
+	for _, s := range staged {
+		if s.backup != "" {
+			_ = os.Remove(s.backup)
+		}
+		result.Written = append(result.Written, s.path)
+	}
+
+	return result, nil
+}
+
+// writeTemp writes content to a new temp file next to target.
+func writeTemp(target string, content []byte, mode fs.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), mode)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// mkdirAll creates dir and its missing parents, returning the directories it
+// created from the outermost in.
+func mkdirAll(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		missing = append(missing, d)
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+
+	var created []string
+	for i := len(missing) - 1; i >= 0; i-- {
+		err := os.Mkdir(missing[i], 0o755)
+		if errors.Is(err, fs.ErrExist) {
+			// created concurrently by someone else, so not ours to roll back
+			if info, serr := os.Stat(missing[i]); serr == nil && info.IsDir() {
+				continue
+			}
+		}
+		if err != nil {
+			return created, err
+		}
+		created = append(created, missing[i])
+	}
+	return created, nil
+}
@@ -0,0 +1,140 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goliatone/go-template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFilesAtomic(t *testing.T) {
+	dest := writeTemplates(t, map[string]string{
+		"same.txt": "same\n",
+		"edit.sh":  "old\n",
+	})
+	require.NoError(t, os.Chmod(filepath.Join(dest, "edit.sh"), 0o755))
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dest, "same.txt"), past, past))
+
+	result, err := template.WriteFilesAtomic(dest, []template.GeneratedFile{
+		{Path: "same.txt", Content: []byte("same\n")},
+		{Path: "edit.sh", Content: []byte("new\n"), Mode: 0o644},
+		{Path: "nested/dir/new.txt", Content: []byte("created\n"), Mode: 0o600},
+	}, template.WithWriteSkipUnchanged(true))
+	require.NoError(t, err)
+	require.Equal(t, []string{"edit.sh", "nested/dir/new.txt"}, result.Written)
+	require.Equal(t, []string{"same.txt"}, result.Skipped)
+
+	info, err := os.Stat(filepath.Join(dest, "same.txt"))
+	require.NoError(t, err)
+	require.Equal(t, past, info.ModTime(), "unchanged files are not touched")
+
+	info, err = os.Stat(filepath.Join(dest, "edit.sh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o755), info.Mode().Perm(), "existing permissions are preserved")
+
+	info, err = os.Stat(filepath.Join(dest, "nested", "dir", "new.txt"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dest)
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "no temp or backup files are left behind")
+}
+
+func TestWriteFilesAtomic_Rollback(t *testing.T) {
+	dest := writeTemplates(t, map[string]string{
+		"keep.txt": "original\n",
+		"blocker":  "a regular file\n",
+	})
+
+	_, err := template.WriteFilesAtomic(dest, []template.GeneratedFile{
+		{Path: "keep.txt", Content: []byte("changed\n")},
+		{Path: "fresh/new.txt", Content: []byte("new\n")},
+		{Path: "blocker/child.txt", Content: []byte("cannot be written\n")},
+	})
+	require.ErrorContains(t, err, "blocker/child.txt")
+
+	content, err := os.ReadFile(filepath.Join(dest, "keep.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "original\n", string(content))
+
+	entries, err := os.ReadDir(dest)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	require.ElementsMatch(t, []string{"keep.txt", "blocker"}, names)
+}
+
+func TestWriteFilesAtomic_Policies(t *testing.T) {
+	dest := writeTemplates(t, map[string]string{
+		"config.yaml":  "hand: tuned\n",
+		"model.go":     "// Code generated by go-template. DO NOT EDIT.\npackage old\n",
+		"handler.go":   "package taken_over\n",
+		"identical.go": "package same\n",
+		"always.txt":   "old\n",
+	})
+
+	files := []template.GeneratedFile{
+		{Path: "config.yaml", Content: []byte("hand: default\n"), Policy: template.WriteCreateOnly},
+		{Path: "fresh.yaml", Content: []byte("new: true\n"), Policy: template.WriteCreateOnly},
+		{Path: "model.go", Content: []byte("// Code generated by go-template. DO NOT EDIT.\npackage models\n"), Policy: template.WriteIfGenerated},
+		{Path: "handler.go", Content: []byte("package handlers\n"), Policy: template.WriteIfGenerated},
+		{Path: "identical.go", Content: []byte("package same\n"), Policy: template.WriteSkipIdentical},
+		{Path: "always.txt", Content: []byte("new\n")},
+	}
+
+	changes, err := template.Plan(dest, files)
+	require.NoError(t, err)
+	kinds := make(map[string]template.ChangeKind)
+	for _, c := range changes {
+		kinds[c.Path] = c.Kind
+	}
+	require.Equal(t, template.ChangeKept, kinds["config.yaml"])
+	require.Equal(t, template.ChangeKept, kinds["handler.go"])
+	require.Equal(t, template.ChangeModified, kinds["model.go"])
+
+	result, err := template.WriteFilesAtomic(dest, files)
+	require.NoError(t, err)
+	require.Equal(t, []string{"fresh.yaml", "model.go", "always.txt"}, result.Written)
+	require.Equal(t, []string{"config.yaml", "handler.go"}, result.Kept)
+	require.Equal(t, []string{"identical.go"}, result.Skipped)
+
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(dest, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+	require.Equal(t, "hand: tuned\n", read("config.yaml"))
+	require.Equal(t, "package taken_over\n", read("handler.go"))
+	require.Contains(t, read("model.go"), "package models")
+
+	// A default policy applies to files without one.
+	result, err = template.WriteFilesAtomic(dest, []template.GeneratedFile{
+		{Path: "always.txt", Content: []byte("newer\n")},
+	}, template.WithWritePolicy(template.WriteCreateOnly))
+	require.NoError(t, err)
+	require.Equal(t, []string{"always.txt"}, result.Kept)
+}
+
+func TestParseWritePolicy(t *testing.T) {
+	policy, err := template.ParseWritePolicy("")
+	require.NoError(t, err)
+	require.Equal(t, template.WriteAlways, policy)
+
+	policy, err = template.ParseWritePolicy("Create-Only")
+	require.NoError(t, err)
+	require.Equal(t, template.WriteCreateOnly, policy)
+
+	_, err = template.ParseWritePolicy("sometimes")
+	require.EqualError(t, err, `unknown write policy "sometimes"`)
+
+	require.True(t, template.IsGenerated([]byte("#!/bin/sh\n# Code generated by tool. DO NOT EDIT.\n")))
+	require.False(t, template.IsGenerated([]byte("package main\n")))
+}